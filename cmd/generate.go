@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+var generateCMD = &cobra.Command{
+	Use:   "generate [packages]",
+	Short: "Create repositories from the ct: comments of the structs of packages, e.g. from //go:generate",
+	Long: `Create repositories from the ct: comments of the structs of packages, e.g.
+
+	//go:generate crafting-table generate
+
+	// ct:repository destination=../repository/user.go dialect=postgres
+	// ct:get by=id,status
+	// ct:select where=created_at:gte order=created_at:desc limit=50
+	type User struct {
+
+The packages are the package of the working directory if they are not set, or patterns such as ./...`,
+	// go generate prints the output of every directive, so the banner is not printed
+	Annotations: map[string]string{bannerAnnotation: "false"},
+	RunE:        generate,
+}
+
+func init() {
+	generateCMD.Flags().StringVarP(&tags, "tags", "t", "", "comma-separated tags for selecting repositories, prefix a tag with ! to exclude it")
+	generateCMD.Flags().StringVarP(&verifySchema, "schema", "s", "", "sql file that has the CREATE TABLE statements of the tables, to prepare the generated queries against before writing them")
+	generateCMD.Flags().BoolVar(&dryRun, "dry-run", false, "print the diff of the repositories instead of writing them, and fail if there is any")
+}
+
+func generate(_ *cobra.Command, args []string) error {
+	manifest, err := craftingtable.LoadAnnotations(args, projectConfig.Defaults())
+	if err != nil {
+		return err
+	}
+
+	files, err := generateFiles(manifest)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return printPlan(files)
+	}
+	return craftingtable.WriteFiles(files)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+var (
+	schemaPath        string
+	ddlDialect        string
+	modelDir          string
+	modelPackage      string
+	repositoryDir     string
+	repositoryPackage string
+	ddlManifestPath   string
+	force             bool
+)
+
+var importCMD = &cobra.Command{
+	Use:   "import",
+	Short: "Import commands",
+}
+
+var ddlCMD = &cobra.Command{
+	Use:   "ddl",
+	Short: "Create structs and a starter manifest from CREATE TABLE statements",
+	RunE:  importDDL,
+}
+
+func init() {
+	ddlCMD.Flags().StringVarP(&schemaPath, "file-path", "f", "", "sql file that has the CREATE TABLE statements")
+	ddlCMD.Flags().StringVarP(&ddlDialect, "dialect", "d", string(craftingtable.MySQL), "dialect of the sql file")
+	ddlCMD.Flags().StringVarP(&modelDir, "output", "o", "models", "directory of the struct files")
+	ddlCMD.Flags().StringVar(&modelPackage, "package-name", "", "package name of the struct files, defaults to the name of the output directory")
+	ddlCMD.Flags().StringVar(&repositoryDir, "repository-dir", "repository", "directory of the repositories in the manifest")
+	ddlCMD.Flags().StringVar(&repositoryPackage, "repository-package", "repository", "package name of the repositories in the manifest")
+	ddlCMD.Flags().StringVarP(&ddlManifestPath, "manifest-path", "p", "ct-manifest.yaml", "path of the starter manifest")
+	ddlCMD.Flags().BoolVar(&force, "force", false, "overwrite the existing struct files and manifest")
+}
+
+func importDDL(cmd *cobra.Command, _ []string) error {
+	if schemaPath == "" {
+		fmt.Println("You need to fill --file-path|-f flag")
+		_ = cmd.Help()
+		return nil
+	}
+
+	dialect := craftingtable.Dialect(ddlDialect)
+	if !cmd.Flags().Changed("dialect") {
+		dialect = projectConfig.DialectOr(dialect)
+	}
+
+	packageOfRepositories := repositoryPackage
+	if !cmd.Flags().Changed("repository-package") && projectConfig.PackageName != "" {
+		packageOfRepositories = projectConfig.PackageName
+	}
+
+	schema, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("Error in reading schema: %w", err)
+	}
+
+	files, err := craftingtable.ImportDDL(string(schema), craftingtable.ImportOptions{
+		Dialect:           dialect,
+		ModelDir:          modelDir,
+		ModelPackage:      modelPackage,
+		ManifestPath:      ddlManifestPath,
+		RepositoryDir:     repositoryDir,
+		RepositoryPackage: packageOfRepositories,
+		RepositorySuffix:  projectConfig.OutputSuffix,
+	})
+	if err != nil {
+		return fmt.Errorf("Error in importing %s: %w", schemaPath, err)
+	}
+
+	// models are edited after they are imported, so they are not overwritten by mistake
+	if !force {
+		for _, file := range files {
+			if _, err := os.Stat(file.Path); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", file.Path)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("Error in checking %s: %w", file.Path, err)
+			}
+		}
+	}
+
+	if err := craftingtable.WriteFiles(files); err != nil {
+		return err
+	}
+	for _, file := range files {
+		fmt.Println(file.Path)
+	}
+
+	return nil
+}
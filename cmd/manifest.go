@@ -1,12 +1,12 @@
 package cmd
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"os"
 
-	"github.com/snapp-incubator/crafting-table/internal/build"
-
-	"gopkg.in/yaml.v3"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+	"github.com/snapp-incubator/crafting-table/pkg/verify"
 
 	"github.com/spf13/cobra"
 )
@@ -14,6 +14,11 @@ import (
 var (
 	manifestPath string
 	tags         string
+	checkSchema  string
+	checkDialect string
+	verifySchema string
+	schemaOutput string
+	dryRun       bool
 )
 
 var manifestCMD = &cobra.Command{
@@ -24,37 +29,211 @@ var manifestCMD = &cobra.Command{
 var applyCMD = &cobra.Command{
 	Use:   "apply",
 	Short: "Create repositories from manifest file",
-	Run:   apply,
+	RunE:  apply,
+}
+
+var planCMD = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the changes that apply would make to the repositories, and fail if there are any",
+	// the diff is printed to stdout, e.g. to be piped to a pager or saved as a patch
+	Annotations: map[string]string{bannerAnnotation: "false"},
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return plan()
+	},
+}
+
+var checkCMD = &cobra.Command{
+	Use:   "check",
+	Short: "Check the tables and columns of manifest file against a schema",
+	RunE:  checkManifest,
+}
+
+var validateCMD = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the keys, enums, required fields and function names of manifest file",
+	RunE:  validateManifest,
+}
+
+var schemaCMD = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of manifest files for editors",
+	RunE:  printSchema,
+	// the schema is printed without the banner, so it can be redirected to a file
+	Annotations: map[string]string{bannerAnnotation: "false"},
 }
 
 func init() {
 	applyCMD.Flags().StringVarP(&manifestPath, "manifest-path", "p", "", "generate automatically repositories from ct-manifest file")
-	applyCMD.Flags().StringVarP(&tags, "tags", "t", "", "select tags from ct-manifest file for generating repositories")
+	applyCMD.Flags().StringVarP(&tags, "tags", "t", "", "comma-separated tags for selecting repositories from ct-manifest file, prefix a tag with ! to exclude it")
+	applyCMD.Flags().StringVarP(&verifySchema, "schema", "s", "", "sql file that has the CREATE TABLE statements of the tables, to prepare the generated queries against before writing them")
+	applyCMD.Flags().BoolVar(&dryRun, "dry-run", false, "print the diff of the repositories instead of writing them, like plan")
+	applyCMD.Flags().BoolVarP(&watchMode, "watch", "w", false, "generate the repositories again when the manifest or their sources change")
+
+	planCMD.Flags().StringVarP(&manifestPath, "manifest-path", "p", "", "path of ct-manifest file")
+	planCMD.Flags().StringVarP(&tags, "tags", "t", "", "comma-separated tags for selecting repositories from ct-manifest file, prefix a tag with ! to exclude it")
+	planCMD.Flags().StringVarP(&verifySchema, "schema", "s", "", "sql file that has the CREATE TABLE statements of the tables, to prepare the generated queries against")
+
+	checkCMD.Flags().StringVarP(&manifestPath, "manifest-path", "p", "", "path of ct-manifest file")
+	checkCMD.Flags().StringVarP(&checkSchema, "schema", "s", "", "sql file that has the CREATE TABLE statements of the tables")
+	checkCMD.Flags().StringVarP(&checkDialect, "dialect", "d", "", "dialect of the sql file, defaults to the dialect of the first repository")
+
+	validateCMD.Flags().StringVarP(&manifestPath, "manifest-path", "p", "", "path of ct-manifest file")
+
+	schemaCMD.Flags().StringVarP(&schemaOutput, "output", "o", "", "file to write the schema to, defaults to the standard output")
 }
 
-func apply(_ *cobra.Command, _ []string) {
-	if manifestPath == "" {
-		panic("manifest path is not set")
+func apply(_ *cobra.Command, _ []string) error {
+	if watchMode {
+		if manifestPath == "" {
+			return errors.New("manifest path is not set, use --manifest-path")
+		}
+		return watchTargets(manifestTargets)
+	}
+	if dryRun {
+		return plan()
 	}
 
-	var manifest build.Repo
-	file, err := os.Open(manifestPath)
+	files, err := generateManifest()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer func(file *os.File) {
-		err := file.Close()
+
+	return craftingtable.WriteFiles(files)
+}
+
+// plan prints the unified diff of every repository that differs from the file on disk, and fails if there is any,
+// so CI can check that the generated code is up to date.
+func plan() error {
+	files, err := generateManifest()
+	if err != nil {
+		return err
+	}
+
+	return printPlan(files)
+}
+
+// printPlan prints the unified diff of every generated file that differs from the file on disk, and fails if there
+// is any.
+func printPlan(files []craftingtable.File) error {
+	changes, err := craftingtable.Plan(files)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%d generated files are up to date\n", len(files))
+		return nil
+	}
+
+	for _, change := range changes {
+		diff, err := change.UnifiedDiff()
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff)
+	}
+
+	return fmt.Errorf("%d of %d generated files would change", len(changes), len(files))
+}
+
+// generateManifest returns the repositories of the manifest of the flags.
+func generateManifest() ([]craftingtable.File, error) {
+	manifest, err := loadManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	return generateFiles(manifest)
+}
+
+// generateFiles returns the repositories of a manifest with the options of the flags, whose queries are verified if
+// a schema is set.
+func generateFiles(manifest *craftingtable.Manifest) ([]craftingtable.File, error) {
+	options := craftingtable.Options{Tags: tags, Templates: templatesDir}
+	if verifySchema != "" {
+		verifier, err := verify.NewSQLiteVerifier(verifySchema)
 		if err != nil {
-			panic(err)
+			return nil, fmt.Errorf("Error in reading schema: %w", err)
 		}
-	}(file)
+		defer func() {
+			_ = verifier.Close()
+		}()
+		options.Verifier = verifier
+	}
+
+	return craftingtable.Generate(manifest, options)
+}
 
-	d := yaml.NewDecoder(file)
-	if err := d.Decode(&manifest); err != nil {
-		panic(err)
+// loadManifest loads the manifest of the flags.
+func loadManifest() (*craftingtable.Manifest, error) {
+	if manifestPath == "" {
+		return nil, errors.New("manifest path is not set, use --manifest-path")
+	}
+
+	manifest, err := craftingtable.LoadManifest(manifestPath, projectConfig.Defaults())
+	if err != nil {
+		return nil, fmt.Errorf("Error in loading %s: %w", manifestPath, err)
+	}
+
+	return manifest, nil
+}
+
+func checkManifest(_ *cobra.Command, _ []string) error {
+	if checkSchema == "" {
+		return errors.New("schema path is not set, use --schema")
+	}
+
+	manifest, err := loadManifest()
+	if err != nil {
+		return err
+	}
+
+	schema, err := os.ReadFile(checkSchema)
+	if err != nil {
+		return fmt.Errorf("Error in reading schema: %w", err)
+	}
+
+	problems, err := craftingtable.Check(manifest, string(schema), craftingtable.Dialect(checkDialect))
+	if err != nil {
+		return fmt.Errorf("Error in parsing %s: %w", checkSchema, err)
+	}
+	for _, problem := range problems {
+		file := problem.File
+		if file == "" {
+			file = manifestPath
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%s:%d: %s\n", file, problem.Line, problem.Message)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s matches %s\n", manifestPath, checkSchema)
+	return nil
+}
+
+func validateManifest(_ *cobra.Command, _ []string) error {
+	if manifestPath == "" {
+		return errors.New("manifest path is not set, use --manifest-path")
+	}
+
+	if err := craftingtable.ValidateManifest(manifestPath, projectConfig.Defaults()); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s is valid\n", manifestPath)
+	return nil
+}
+
+func printSchema(_ *cobra.Command, _ []string) error {
+	schema, err := craftingtable.ManifestSchema()
+	if err != nil {
+		return err
 	}
+	schema = append(schema, '\n')
 
-	if err := build.Generate(manifest); err != nil {
-		log.Fatal(err)
+	if schemaOutput == "" {
+		_, err = os.Stdout.Write(schema)
+		return err
 	}
+	return os.WriteFile(schemaOutput, schema, 0o644)
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+var (
+	migrationModelPath string
+	migrationDialect   string
+	migrationDir       string
+	migrationName      string
+)
+
+var migrateCMD = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migration commands",
+}
+
+var migrateGenerateCMD = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate the up and down migrations of the annotated structs since the last migration",
+	RunE:  generateMigration,
+}
+
+func init() {
+	migrateGenerateCMD.Flags().StringVarP(&migrationModelPath, "file-path", "f", "", "file, directory or package of the structs that are annotated with `ct: model`")
+	migrateGenerateCMD.Flags().StringVarP(&migrationDialect, "dialect", "d", string(craftingtable.MySQL), "dialect of the migrations")
+	migrateGenerateCMD.Flags().StringVarP(&migrationDir, "output", "o", "migrations", "directory of the migrations and their snapshot")
+	migrateGenerateCMD.Flags().StringVarP(&migrationName, "name", "n", "schema", "name of the migration files")
+}
+
+func generateMigration(cmd *cobra.Command, _ []string) error {
+	if migrationModelPath == "" {
+		fmt.Println("You need to fill --file-path|-f flag")
+		_ = cmd.Help()
+		return nil
+	}
+
+	dialect := craftingtable.Dialect(migrationDialect)
+	if !cmd.Flags().Changed("dialect") {
+		dialect = projectConfig.DialectOr(dialect)
+	}
+	switch dialect {
+	case craftingtable.MySQL, craftingtable.Postgres, craftingtable.SQLite3, craftingtable.SQLServer:
+	default:
+		return fmt.Errorf("dialect %q is not supported", dialect)
+	}
+
+	files, err := craftingtable.Migration(migrationModelPath, craftingtable.MigrationOptions{
+		Dialect:      dialect,
+		TableNaming:  craftingtable.TableNaming(projectConfig.TableNaming),
+		Dir:          migrationDir,
+		Name:         migrationName,
+		OutputSuffix: projectConfig.OutputSuffix,
+	})
+	if err != nil {
+		return fmt.Errorf("Error in generating migration of %s: %w", migrationModelPath, err)
+	}
+	if len(files) == 0 {
+		fmt.Println("no changes")
+		return nil
+	}
+
+	if err := craftingtable.WriteFiles(files); err != nil {
+		return fmt.Errorf("Error in writing migration: %w", err)
+	}
+	// the last file is the snapshot
+	for _, file := range files[:len(files)-1] {
+		fmt.Println(file.Path)
+	}
+
+	return nil
+}
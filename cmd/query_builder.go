@@ -2,16 +2,12 @@ package cmd
 
 import (
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"os"
-	"path/filepath"
-	"strings"
 
 	"github.com/spf13/cobra"
 
-	"github.com/snapp-incubator/crafting-table/internal/querybuilder"
+	"github.com/snapp-incubator/crafting-table/internal/config"
+	"github.com/snapp-incubator/crafting-table/internal/watch"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
 )
 
 var (
@@ -24,61 +20,52 @@ var queryBuilderCmd = &cobra.Command{
 	Use:     "query-builder",
 	Aliases: []string{"qb"},
 	Short:   `Generates a query builder based on annotations in your code`,
-	Run: func(cmd *cobra.Command, args []string) {
-		generateQueryBuilder(cmd, filePath)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return generateQueryBuilder(cmd, filePath)
 	},
 }
 
 func init() {
-	queryBuilderCmd.Flags().StringVarP(&filePath, "file-path", "f", "", "which file you want to parse and generate query builder for")
-	queryBuilderCmd.Flags().StringVarP(&dialect, "dialect", "d", "mysql", "dialect you want to generate sql for")
+	queryBuilderCmd.Flags().StringVarP(&filePath, "file-path", "f", "", "which file, directory or package you want to parse and generate query builder for")
+	queryBuilderCmd.Flags().StringVarP(&dialect, "dialect", "d", "mysql", "dialect you want to generate sql for, defaults to the dialect of "+config.FileName+" or mysql")
+	queryBuilderCmd.Flags().BoolVarP(&watchMode, "watch", "w", false, "generate the query builders again when the files of the structs change")
 	queryBuilderCmd.Flags().StringVarP(&table, "table", "t", "", "table name of the type if not specified defaults to snakeCase(plural(typeName))")
 }
 
-func generateQueryBuilder(cmd *cobra.Command, filePath string) {
+func generateQueryBuilder(cmd *cobra.Command, filePath string) error {
 	if filePath == "" {
 		fmt.Println("You need to fill --file-path|-f flag")
 		_ = cmd.Help()
-		return
+		return nil
 	}
 
-	inputFilePath, err := filepath.Abs(filePath)
-	if err != nil {
-		panic(err)
+	if !cmd.Flags().Changed("dialect") {
+		dialect = string(projectConfig.DialectOr(craftingtable.Dialect(dialect)))
 	}
 
-	pathList := filepath.SplitList(inputFilePath)
-	pathList = pathList[:len(pathList)-1]
-	fileDir := filepath.Join(pathList...)
-	fileSet := token.NewFileSet()
-	fileAst, err := parser.ParseFile(fileSet, filePath, nil, parser.ParseComments)
-	if err != nil {
-		panic(err)
+	options := craftingtable.QueryBuilderOptions{
+		Dialect:      craftingtable.Dialect(dialect),
+		TableNaming:  craftingtable.TableNaming(projectConfig.TableNaming),
+		OutputSuffix: projectConfig.OutputSuffix,
+		Templates:    templatesDir,
+	}
+	if watchMode {
+		// the query builders of a package are generated together, since their types are resolved across its files
+		return watchTargets(func() ([]watch.Target, []string, error) {
+			return []watch.Target{{
+				Name:  filePath,
+				Files: sourceFiles(filePath),
+				Generate: func() ([]craftingtable.File, error) {
+					return craftingtable.QueryBuilders(filePath, options)
+				},
+			}}, nil, nil
+		})
 	}
 
-	actualName := strings.TrimSuffix(filePath, filepath.Ext(filePath))
-	outputFilePath := filepath.Join(fileDir, fmt.Sprintf("%s_ct_gen.go", actualName))
-	outputFile, err := os.Create(outputFilePath)
+	files, err := craftingtable.QueryBuilders(filePath, options)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	defer func(outputFile *os.File) {
-		err := outputFile.Close()
-		if err != nil {
-			fmt.Println(err)
-		}
-	}(outputFile)
 
-	for _, decl := range fileAst.Decls {
-		if _, ok := decl.(*ast.GenDecl); ok {
-			declComment := decl.(*ast.GenDecl).Doc.Text()
-			if len(declComment) > 0 && declComment[:len(querybuilder.ModelAnnotation)] == querybuilder.ModelAnnotation {
-				output := querybuilder.Generate(dialect, fileAst.Name.String(), decl.(*ast.GenDecl))
-				_, err := fmt.Fprint(outputFile, output)
-				if err != nil {
-					panic(err)
-				}
-			}
-		}
-	}
+	return craftingtable.WriteFiles(files)
 }
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/snapp-incubator/crafting-table/internal/config"
 )
 
 const asciiArt = `
@@ -18,11 +20,38 @@ const asciiArt = `
 var rootCMD = &cobra.Command{
 	Use:   "crafting-table",
 	Short: "A repository for repository based struct",
+	// errors are printed by Execute, and they are not mistakes in the usage of the commands
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+		c, err := config.Find(".")
+		if err != nil {
+			return err
+		}
+		projectConfig = c
+		if templatesDir == "" {
+			templatesDir = c.TemplatesDir()
+		}
+		return c.RegisterGenerators()
+	},
 }
 
+// templatesDir is the directory of the templates that override the default templates.
+var templatesDir string
+
+// projectConfig is the configuration of the project, whose values are the defaults of the flags and manifests. It is
+// loaded, and its generators are registered, before every command.
+var projectConfig = &config.Config{}
+
+// bannerAnnotation is the annotation of the commands that do not print the ascii art, e.g. the ones whose output is
+// redirected to a file
+const bannerAnnotation = "banner"
+
 // Execute executes the root command.
 func Execute() {
-	fmt.Print(asciiArt)
+	if cmd, _, err := rootCMD.Find(os.Args[1:]); err != nil || cmd.Annotations[bannerAnnotation] != "false" {
+		fmt.Print(asciiArt)
+	}
 	if err := rootCMD.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
@@ -30,6 +59,10 @@ func Execute() {
 }
 
 func init() {
-	manifestCMD.AddCommand(applyCMD)
-	rootCMD.AddCommand(manifestCMD, queryBuilderCmd)
+	rootCMD.PersistentFlags().StringVar(&templatesDir, "templates", "", "directory of templates that override the templates of the generated code, defaults to the templates of "+config.FileName)
+
+	manifestCMD.AddCommand(applyCMD, planCMD, checkCMD, validateCMD, schemaCMD)
+	importCMD.AddCommand(ddlCMD)
+	migrateCMD.AddCommand(migrateGenerateCMD)
+	rootCMD.AddCommand(manifestCMD, generateCMD, queryBuilderCmd, importCMD, migrateCMD)
 }
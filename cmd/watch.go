@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/watch"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+// watchMode is set by --watch of the commands that generate the targets again when their files change.
+var watchMode bool
+
+// watchTargets generates the targets of a loader again when their files change, until the command is interrupted.
+func watchTargets(load watch.Loader) error {
+	if dryRun {
+		return errors.New("--watch and --dry-run cannot be used together")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return watch.Watch(ctx, load, watch.Options{Out: os.Stdout})
+}
+
+// manifestTargets returns a target for every repository of the manifest of the flags, which is generated again when
+// its source changes, or when its entry changes in the manifest.
+func manifestTargets() ([]watch.Target, []string, error) {
+	files := []string{manifestPath}
+
+	manifest, err := loadManifest()
+	if err != nil {
+		return nil, files, err
+	}
+
+	var targets []watch.Target
+	for i, repo := range manifest.Repositories {
+		repository := manifest.Subset(i)
+		if len(repository.SelectRepositories(tags)) == 0 {
+			continue
+		}
+
+		key, err := json.Marshal(repo)
+		if err != nil {
+			return nil, files, err
+		}
+		targets = append(targets, watch.Target{
+			Name:  repo.Destination,
+			Key:   string(key),
+			Files: sourceFiles(repo.Source),
+			Generate: func() ([]craftingtable.File, error) {
+				return generateFiles(repository)
+			},
+		})
+	}
+
+	return targets, files, nil
+}
+
+// sourceFiles returns the files to watch of a Go file, a directory or the import path of a package. An import path
+// is resolved to the directory of its package by the loader of the structs, so its Go files are watched. A path that
+// can not be resolved is returned as is, and its target reports the problem when it is generated.
+func sourceFiles(path string) []string {
+	if _, err := os.Stat(path); err == nil {
+		return []string{path}
+	}
+
+	pkg, err := structure.LoadPackage(path)
+	if err != nil || len(pkg.GoFiles) == 0 {
+		return []string{path}
+	}
+	return []string{filepath.Dir(pkg.GoFiles[0])}
+}
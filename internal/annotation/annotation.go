@@ -0,0 +1,555 @@
+// Package annotation reads the repositories that are declared by ct: comments on structs, e.g.
+//
+//	// ct:repository destination=../repository/user.go dialect=postgres
+//	// ct:get by=id
+//	// ct:select where=created_at:gte order=created_at:desc limit=50
+//	type User struct {
+//
+// into the same manifest values as the manifest files.
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// Prefix is the prefix of the comments that declare a repository and its functions. It is followed by the kind of
+// the declaration without a space, so `ct: model` comments of query builders are not declarations.
+const Prefix = "ct:"
+
+// RepositoryKind is the kind of the declaration of the repository of a struct.
+const RepositoryKind = "repository"
+
+// Load returns the manifest of the repositories of the structs of the packages of patterns, e.g. "./...". Paths of
+// the declarations are relative to the directory of the file of the struct. The values that a declaration does not
+// set are taken from the defaults. The problems of the declarations are returned together as Diagnostics with their
+// lines in the Go files.
+func Load(patterns []string, defaults build.Defaults) (*build.Manifest, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	fileSet := token.NewFileSet()
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Fset: fileSet,
+	}
+	pkgs, err := packages.Load(config, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{fileSet: fileSet, defaults: defaults}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, errors.New(pkg.Errors[0].Error())
+		}
+
+		for _, file := range pkg.Syntax {
+			p.parseFile(file)
+		}
+	}
+
+	if len(p.diagnostics) > 0 {
+		return nil, p.diagnostics
+	}
+	if len(p.repos) == 0 {
+		return nil, fmt.Errorf("no struct annotated with `%s%s` found", Prefix, RepositoryKind)
+	}
+
+	return build.NewManifest(p.repos, p.positions), nil
+}
+
+// directive is a ct: comment, e.g. `ct:get by=id`, with its line.
+type directive struct {
+	kind      string
+	arguments []argument
+	line      int
+}
+
+// argument is a key=value pair of a directive, or a key without a value, e.g. `bulk`.
+type argument struct {
+	key   string
+	value string
+	flag  bool
+}
+
+// directives returns the ct: comments of a comment group.
+func directives(fileSet *token.FileSet, doc *ast.CommentGroup) []directive {
+	if doc == nil {
+		return nil
+	}
+
+	var list []directive
+	for _, comment := range doc.List {
+		text := strings.TrimPrefix(comment.Text, "//")
+		if text == comment.Text {
+			continue
+		}
+
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, Prefix) {
+			continue
+		}
+
+		fields := strings.Fields(text[len(Prefix):])
+		if len(fields) == 0 || !strings.HasPrefix(text[len(Prefix):], fields[0]) {
+			continue
+		}
+
+		d := directive{kind: fields[0], line: fileSet.Position(comment.Pos()).Line}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			d.arguments = append(d.arguments, argument{key: key, value: value, flag: !ok})
+		}
+		list = append(list, d)
+	}
+
+	return list
+}
+
+type parser struct {
+	fileSet  *token.FileSet
+	defaults build.Defaults
+
+	repos       []build.Repo
+	positions   []build.Position
+	diagnostics build.Diagnostics
+}
+
+// parseFile adds the repositories of the annotated structs of a file.
+func (p *parser) parseFile(file *ast.File) {
+	path := relativePath(p.fileSet.File(file.Pos()).Name())
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			// the doc of a single type is the doc of its declaration
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+
+			list := directives(p.fileSet, doc)
+			if len(list) == 0 {
+				continue
+			}
+			if _, ok := typeSpec.Type.(*ast.StructType); !ok {
+				p.report(path, list[0].line, "", "", "", "%s annotations are only supported on structs, %s is not a struct",
+					Prefix, typeSpec.Name.Name)
+				continue
+			}
+
+			p.parseStruct(path, typeSpec.Name.Name, list)
+		}
+	}
+}
+
+// parseStruct adds the repository of a struct from its directives.
+func (p *parser) parseStruct(path, structName string, list []directive) {
+	dir := filepath.Dir(path)
+	diagnostics := len(p.diagnostics)
+
+	var declaration *directive
+	for i := range list {
+		if list[i].kind != RepositoryKind {
+			continue
+		}
+		if declaration != nil {
+			p.report(path, list[i].line, "", "", "", "%s%s is declared twice for %s", Prefix, RepositoryKind, structName)
+			continue
+		}
+		declaration = &list[i]
+	}
+	if declaration == nil {
+		p.report(path, list[0].line, "", "", "", "%s%s is not declared for %s, whose functions are annotated", Prefix,
+			RepositoryKind, structName)
+		return
+	}
+
+	repo := p.parseRepository(path, dir, structName, *declaration)
+	position := build.Position{File: path, Line: declaration.line, Entries: make(map[string][]int)}
+
+	for _, d := range list {
+		if d.kind == RepositoryKind {
+			continue
+		}
+
+		section := d.kind
+		if section == string(build.SelectTypeGet) {
+			section = "select"
+		}
+		entry := entryParser{
+			parser: p,
+			path:   path,
+			repo:   repo.Destination,
+			entry:  fmt.Sprintf("%s[%d]", section, len(position.Entries[section])),
+			d:      d,
+		}
+
+		switch d.kind {
+		case string(build.SelectTypeGet), string(build.SelectTypeSelect):
+			repo.Select = append(repo.Select, entry.parseSelect())
+		case "insert":
+			repo.Insert = append(repo.Insert, entry.parseInsert())
+		case "update":
+			repo.Update = append(repo.Update, entry.parseUpdate())
+		case "delete":
+			repo.Delete = append(repo.Delete, entry.parseDelete())
+		default:
+			if generator.Lookup(d.kind) == nil {
+				p.report(path, d.line, repo.Destination, "", "", "unknown annotation %s%s", Prefix, d.kind)
+				continue
+			}
+			if repo.Sections == nil {
+				repo.Sections = make(map[string]interface{})
+			}
+			entries, _ := repo.Sections[section].([]interface{})
+			repo.Sections[section] = append(entries, entry.parseSection())
+		}
+
+		position.Entries[section] = append(position.Entries[section], d.line)
+	}
+
+	if len(p.diagnostics) > diagnostics {
+		return
+	}
+	p.repos = append(p.repos, repo)
+	p.positions = append(p.positions, position)
+}
+
+// parseRepository returns the repository of a `ct:repository` directive, whose paths are relative to the directory of
+// the struct file.
+func (p *parser) parseRepository(path, dir, structName string, d directive) build.Repo {
+	repo := build.Repo{
+		Source:      path,
+		StructName:  structName,
+		Dialect:     p.defaults.Dialect,
+		PackageName: p.defaults.PackageName,
+		DBLibrary:   p.defaults.DBLibrary,
+		TableNaming: p.defaults.TableNaming,
+	}
+	if p.defaults.Test != nil {
+		repo.Test = *p.defaults.Test
+	}
+
+	entry := entryParser{parser: p, path: path, d: d}
+	entry.parse(map[string]func(string) error{
+		"source": func(value string) error {
+			repo.Source = filepath.Join(dir, value)
+			return nil
+		},
+		"destination": func(value string) error {
+			repo.Destination = filepath.Join(dir, value)
+			return nil
+		},
+		"package_name": stringValue(&repo.PackageName),
+		"dialect": func(value string) error {
+			repo.Dialect = build.DialectType(value)
+			return enumValue(repo.Dialect)
+		},
+		"table_name": stringValue(&repo.TableName),
+		"table_naming": func(value string) error {
+			repo.TableNaming = internalStruct.TableNaming(value)
+			return enumValue(repo.TableNaming)
+		},
+		"db_library":         stringValue(&repo.DBLibrary),
+		"soft_delete_column": stringValue(&repo.SoftDeleteColumn),
+		"tag":                stringValue(&repo.Tag),
+		"tags":               listValue(&repo.Tags),
+		"test":               boolValue(&repo.Test),
+	}, "test")
+
+	if repo.Destination == "" {
+		p.report(path, d.line, "", "", "destination", "destination is required")
+	}
+	if repo.Dialect == "" {
+		p.report(path, d.line, repo.Destination, "", "dialect", "dialect is required")
+	}
+	// the package of the repository is named by its directory if neither the declaration nor the defaults set it
+	if repo.PackageName == "" && repo.Destination != "" {
+		if absolutePath, err := filepath.Abs(repo.Destination); err == nil {
+			repo.PackageName = filepath.Base(filepath.Dir(absolutePath))
+		}
+	}
+
+	return repo
+}
+
+// report adds a diagnostic at a line of a Go file.
+func (p *parser) report(path string, line int, repository, entry, field, format string, args ...interface{}) {
+	p.diagnostics = append(p.diagnostics, build.Diagnostic{
+		Manifest:   path,
+		Line:       line,
+		Repository: repository,
+		Entry:      entry,
+		Field:      field,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+// entryParser parses the arguments of a directive into an entry of a repository.
+type entryParser struct {
+	*parser
+	path  string
+	repo  string
+	entry string
+	d     directive
+}
+
+// parse sets the values of the arguments by their keys. Flags are the keys that can be set without a value, to true.
+func (e entryParser) parse(setters map[string]func(string) error, flags ...string) {
+	seen := make(map[string]bool)
+	for _, arg := range e.d.arguments {
+		setter, ok := setters[arg.key]
+		if !ok {
+			e.report(e.path, e.d.line, e.repo, e.entry, "", "unknown key %q of %s%s", arg.key, Prefix, e.d.kind)
+			continue
+		}
+		if seen[arg.key] {
+			e.report(e.path, e.d.line, e.repo, e.entry, arg.key, "%s is set twice", arg.key)
+			continue
+		}
+		seen[arg.key] = true
+
+		value := arg.value
+		if arg.flag {
+			if !hasString(flags, arg.key) {
+				e.report(e.path, e.d.line, e.repo, e.entry, arg.key, "%s needs a value, e.g. %s=...", arg.key, arg.key)
+				continue
+			}
+			value = "true"
+		}
+
+		if err := setter(value); err != nil {
+			e.report(e.path, e.d.line, e.repo, e.entry, arg.key, "%s", err.Error())
+		}
+	}
+}
+
+// require reports a key that the directive must set.
+func (e entryParser) require(key string, set bool) {
+	if !set {
+		e.report(e.path, e.d.line, e.repo, e.entry, key, "%s is required for %s%s", key, Prefix, e.d.kind)
+	}
+}
+
+// parseSelect parses `ct:get` and `ct:select`, e.g. `ct:select where=created_at:gte order=created_at:desc limit=50`.
+// by sets equal conditions, and where sets conditions of `column:operator`.
+func (e entryParser) parseSelect() build.Select {
+	sel := build.Select{Type: build.SelectType(e.d.kind)}
+	e.parse(map[string]func(string) error{
+		"name":     stringValue(&sel.FunctionName),
+		"fields":   listValue(&sel.Fields),
+		"by":       conditionsValue(&sel.WhereConditions, false),
+		"where":    conditionsValue(&sel.WhereConditions, true),
+		"group_by": listValue(&sel.GroupBy),
+		"order": func(value string) error {
+			column, order, ok := strings.Cut(value, ":")
+			sel.OrderBy, sel.OrderType = column, build.OrderTypeAsc
+			if ok {
+				sel.OrderType = build.OrderType(order)
+			}
+			if column == "" {
+				return errors.New("column of order is empty")
+			}
+			return enumValue(sel.OrderType)
+		},
+		"limit": func(value string) error {
+			limit, err := strconv.ParseUint(value, 10, 0)
+			if err != nil {
+				return fmt.Errorf("limit %q is not a number", value)
+			}
+			sel.Limit = uint(limit)
+			return nil
+		},
+	})
+
+	return sel
+}
+
+// parseInsert parses `ct:insert`, e.g. `ct:insert fields=name,email bulk batch_size=100`.
+func (e entryParser) parseInsert() build.Insert {
+	var insert build.Insert
+	e.parse(map[string]func(string) error{
+		"name":   stringValue(&insert.FunctionName),
+		"fields": listValue(&insert.Fields),
+		"object": boolValue(&insert.WithObject),
+		"bulk":   boolValue(&insert.Bulk),
+		"batch_size": func(value string) error {
+			batchSize, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("batch_size %q is not a number", value)
+			}
+			insert.BatchSize = batchSize
+			return nil
+		},
+	}, "object", "bulk")
+	e.require("fields", len(insert.Fields) > 0)
+
+	return insert
+}
+
+// parseUpdate parses `ct:update`, e.g. `ct:update fields=name by=id`.
+func (e entryParser) parseUpdate() build.Update {
+	var update build.Update
+	e.parse(map[string]func(string) error{
+		"name":   stringValue(&update.FunctionName),
+		"fields": listValue(&update.Fields),
+		"by":     conditionsValue(&update.WhereConditions, false),
+		"where":  conditionsValue(&update.WhereConditions, true),
+		"object": boolValue(&update.WithObject),
+	}, "object")
+	e.require("fields", len(update.Fields) > 0)
+
+	return update
+}
+
+// parseDelete parses `ct:delete`, e.g. `ct:delete by=id`, or `ct:delete all_rows` for every row.
+func (e entryParser) parseDelete() build.Delete {
+	var del build.Delete
+	e.parse(map[string]func(string) error{
+		"name":     stringValue(&del.FunctionName),
+		"by":       conditionsValue(&del.WhereConditions, false),
+		"where":    conditionsValue(&del.WhereConditions, true),
+		"all_rows": boolValue(&del.AllRows),
+	}, "all_rows")
+
+	return del
+}
+
+// parseSection parses the directive of the section of a registered generator into a mapping entry, whose values
+// are decoded as yaml scalars, e.g. `ct:archive column=archived_at days=30`. Keys without values are true.
+func (e entryParser) parseSection() map[string]interface{} {
+	entry := make(map[string]interface{})
+	for _, arg := range e.d.arguments {
+		if _, ok := entry[arg.key]; ok {
+			e.report(e.path, e.d.line, e.repo, e.entry, arg.key, "%s is set twice", arg.key)
+			continue
+		}
+		if arg.flag {
+			entry[arg.key] = true
+			continue
+		}
+
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(arg.value), &value); err != nil {
+			value = arg.value
+		}
+		entry[arg.key] = value
+	}
+
+	return entry
+}
+
+func stringValue(v *string) func(string) error {
+	return func(value string) error {
+		*v = value
+		return nil
+	}
+}
+
+// listValue sets a comma-separated list.
+func listValue(v *[]string) func(string) error {
+	return func(value string) error {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*v = append(*v, item)
+			}
+		}
+		return nil
+	}
+}
+
+func boolValue(v *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*v = b
+		return nil
+	}
+}
+
+// conditionsValue adds the where conditions of a comma-separated list of columns. With operators, a column can be
+// followed by its operator, e.g. `created_at:gte`, and the operator is equal otherwise.
+func conditionsValue(conditions *[]build.WhereCondition, operators bool) func(string) error {
+	return func(value string) error {
+		for _, item := range strings.Split(value, ",") {
+			column, operator, ok := strings.Cut(strings.TrimSpace(item), ":")
+			if column == "" {
+				return fmt.Errorf("column of %q is empty", item)
+			}
+
+			condition := build.WhereCondition{Column: column, Operator: build.OperatorTypeEqual}
+			if ok {
+				if !operators {
+					return fmt.Errorf("%q has an operator, which is set by where", item)
+				}
+				condition.Operator = build.OperatorType(operator)
+				if err := enumValue(condition.Operator); err != nil {
+					return err
+				}
+			}
+			*conditions = append(*conditions, condition)
+		}
+		return nil
+	}
+}
+
+// enumValue returns an error if a value is not one of the values of its enum.
+func enumValue(v interface{}) error {
+	values := build.EnumValues(v)
+	value := fmt.Sprint(v)
+	if hasString(values, value) {
+		return nil
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(values, ", "))
+}
+
+func hasString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// relativePath returns a path relative to the working directory, so the diagnostics and the generated files have
+// the paths that the patterns are written with.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	relative, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return relative
+}
@@ -0,0 +1,116 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// FieldError is a problem of a field of a manifest entry, e.g. a where column that the struct does not have.
+type FieldError = generator.FieldError
+
+// FieldErrors are the problems of several fields of a manifest entry.
+type FieldErrors = generator.FieldErrors
+
+// Diagnostic is a problem of a manifest that stops its repository from being generated.
+type Diagnostic struct {
+	// Manifest is the path of the manifest, and Line is the line of the problem in it, or 0 if it is not known
+	Manifest string
+	Line     int
+	// Repository is the destination of the repository that has the problem
+	Repository string
+	// Entry is the function entry of the repository, e.g. "select[1]", or empty for the problems of the repository
+	Entry string
+	// Function is the name of the function of the entry, if it is known
+	Function string
+	// Field is the yaml key of the entry or the repository that has the problem, e.g. "where_conditions"
+	Field   string
+	Message string
+
+	// section and index are the list and the index of the entry in the repository, and value is the value of the
+	// field, that locate the problem in the yaml nodes of the manifest
+	section string
+	index   int
+	value   string
+}
+
+// diagnosticsOf returns the diagnostics of an error of an entry of a repository, one for each field error. section is
+// empty for the errors of the repository itself.
+func diagnosticsOf(repo Repo, section string, index int, function string, err error) []Diagnostic {
+	d := Diagnostic{
+		Repository: repo.Destination,
+		Function:   function,
+		Message:    err.Error(),
+		section:    section,
+		index:      index,
+	}
+	if section != "" {
+		d.Entry = fmt.Sprintf("%s[%d]", section, index)
+	}
+
+	var fieldErrors FieldErrors
+	if errors.As(err, &fieldErrors) {
+		diagnostics := make([]Diagnostic, len(fieldErrors))
+		for i, fieldError := range fieldErrors {
+			diagnostics[i] = d.withField(fieldError)
+		}
+		return diagnostics
+	}
+
+	var fieldError *FieldError
+	if errors.As(err, &fieldError) {
+		d = d.withField(fieldError)
+	}
+
+	return []Diagnostic{d}
+}
+
+func (d Diagnostic) withField(err *FieldError) Diagnostic {
+	d.Field = err.Field
+	d.Message = err.Message
+	d.value = err.Value
+	return d
+}
+
+// String returns the diagnostic as "manifest.yaml:14: repository/user.go: select[1] (GetByName): field: message".
+func (d Diagnostic) String() string {
+	var builder strings.Builder
+	if d.Manifest != "" {
+		builder.WriteString(d.Manifest + ":")
+		if d.Line > 0 {
+			_, _ = fmt.Fprintf(&builder, "%d:", d.Line)
+		}
+		builder.WriteString(" ")
+	}
+
+	if d.Repository != "" {
+		builder.WriteString(d.Repository + ": ")
+	}
+	if d.Entry != "" {
+		builder.WriteString(d.Entry)
+		if d.Function != "" {
+			builder.WriteString(" (" + d.Function + ")")
+		}
+		builder.WriteString(": ")
+	}
+	if d.Field != "" {
+		builder.WriteString(d.Field + ": ")
+	}
+	builder.WriteString(d.Message)
+
+	return builder.String()
+}
+
+// Diagnostics are the problems of a manifest, which are reported together.
+type Diagnostics []Diagnostic
+
+// Error returns the diagnostics, one per line.
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
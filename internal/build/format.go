@@ -0,0 +1,69 @@
+package build
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/imports"
+)
+
+// Section is a part of a generated file that is built by a template, e.g. the function of a select query.
+type Section struct {
+	Name   string
+	Source string
+}
+
+// FormatSource formats a generated file like `goimports` does. The templates generate code that `gofmt -s` does not
+// simplify. Syntax errors are reported with the section of the file that has them, since the file is built by
+// several templates.
+func FormatSource(path, source string, sections []Section) (string, error) {
+	fileSet := token.NewFileSet()
+	if _, err := parser.ParseFile(fileSet, filepath.Base(path), source, parser.ParseComments); err != nil {
+		return "", sectionError(source, sections, err)
+	}
+
+	formatted, err := imports.Process(path, []byte(source), &imports.Options{
+		Comments:  true,
+		TabIndent: true,
+		TabWidth:  8,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(formatted), nil
+}
+
+// sectionError returns the first syntax error with the section and the line that have it.
+func sectionError(source string, sections []Section, err error) error {
+	var errorList scanner.ErrorList
+	if !errors.As(err, &errorList) || len(errorList) == 0 {
+		return err
+	}
+	syntaxError := errorList[0]
+
+	lines := strings.Split(source, "\n")
+	line := ""
+	if syntaxError.Pos.Line > 0 && syntaxError.Pos.Line <= len(lines) {
+		line = strings.TrimSpace(lines[syntaxError.Pos.Line-1])
+	}
+
+	offset := syntaxError.Pos.Offset
+	for _, section := range sections {
+		start := strings.Index(source, section.Source)
+		if start == -1 || offset < start || offset >= start+len(section.Source) {
+			continue
+		}
+
+		sectionLine := strings.Count(section.Source[:offset-start], "\n") + 1
+		return fmt.Errorf("syntax error in generated %s, line %d: %s\n\t%s",
+			section.Name, sectionLine, syntaxError.Msg, line)
+	}
+
+	return fmt.Errorf("syntax error in generated file, line %d: %s\n\t%s", syntaxError.Pos.Line, syntaxError.Msg, line)
+}
@@ -4,119 +4,236 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
 )
 
-func Generate(repo Repo) error {
-	//testDestination := repo.Destination[:len(repo.Destination)-3] + "_test.go"
+// File is a generated file.
+type File struct {
+	Path    string
+	Content string
+}
 
-	s, err := internalStruct.BindStruct(repo.Source, repo.StructName)
+// Generate writes the repository of a manifest entry. If verifier is not nil, the queries are verified before the
+// repository is written. If tmpl is nil, the repository is generated by DefaultTemplates.
+func Generate(repo Repo, verifier Verifier, tmpl *templates.Set) error {
+	files, err := Render(repo, verifier, tmpl)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("Error in bindStruct: %s", err.Error()))
 		return err
 	}
 
-	tableName := s.TableName
-	if repo.TableName != "" {
-		tableName = repo.TableName
-	}
-
-	var signatureList []string
-	var functionList []string
-
-	// Select
-	for _, r := range repo.Select {
-		if r.Type == SelectTypeGet {
-			function, signature := BuildGetFunction(
-				s,
-				repo.Dialect,
-				tableName,
-				r.Fields,
-				r.WhereConditions,
-				r.AggregateFields,
-				&r.OrderBy,
-				&r.OrderType,
-				&r.Limit,
-				r.GroupBy,
-				r.JoinFields,
-				r.FunctionName,
-			)
-			functionList = append(functionList, function)
-			signatureList = append(signatureList, signature)
-		} else if r.Type == SelectTypeSelect {
-			function, signature := BuildSelectFunction(
-				s,
-				repo.Dialect,
-				tableName,
-				r.Fields,
-				r.WhereConditions,
-				r.AggregateFields,
-				&r.OrderBy,
-				&r.OrderType,
-				&r.Limit,
-				r.GroupBy,
-				r.JoinFields,
-				r.FunctionName,
-			)
-			functionList = append(functionList, function)
-			signatureList = append(signatureList, signature)
+	return WriteFiles(files)
+}
+
+// WriteFiles writes the generated files and creates their directories.
+func WriteFiles(files []File) error {
+	for _, file := range files {
+		if err := exportRepository(file.Content, file.Path); err != nil {
+			err = errors.New(fmt.Sprintf("Error in writeFile: %s", err.Error()))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Render returns the repository of a manifest entry and its test file, without writing them. The functions of the
+// sections of Repo are generated first, and then the ones of the sections of the registered generators by the order
+// of their keys. The problems of every function entry are returned together as Diagnostics. If verifier is not nil,
+// the queries are verified as well. The custom regions of the files on disk are carried over to them. If tmpl is
+// nil, the files are generated by DefaultTemplates.
+func Render(repo Repo, verifier Verifier, tmpl *templates.Set) ([]File, error) {
+	if tmpl == nil {
+		tmpl = DefaultTemplates
+	}
+
+	// the values that the repository cannot be generated without are checked first, with the problems of its source
+	diagnostics := checkValues(repo)
+	s, err := internalStruct.BindStruct(repo.Source, repo.StructName)
+	if err != nil {
+		diagnostics = append(diagnostics, diagnosticsOf(repo, "", 0, "", &FieldError{
+			Field:   "source",
+			Value:   repo.Source,
+			Message: err.Error(),
+		})...)
+	}
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+	tableName := repo.TableNameOf(s)
+
+	var functions []generator.Function
+	var statementList []Statement
+
+	// entries are the section and the index of the entry of each statement
+	type entry struct {
+		section string
+		index   int
+	}
+	var entries []entry
+
+	// the sections of crafting-table are generated first, and the sections of the registered generators in order
+	generators := builtinGenerators(tmpl, s)
+	sections := make([]string, 0, len(repo.Sections))
+	for section := range repo.Sections {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		g := generator.Lookup(section)
+		if g == nil {
+			diagnostics = append(diagnostics, diagnosticsOf(repo, "", 0, "", &FieldError{
+				Field:   section,
+				Message: fmt.Sprintf("unknown field %q, which is not a section of a registered generator", section),
+			})...)
+			continue
+		}
+		generators = append(generators, g)
+	}
+
+	request := generator.Request{
+		Repository: generator.Repository{
+			PackageName:      repo.PackageName,
+			Destination:      repo.Destination,
+			Dialect:          string(repo.Dialect),
+			SoftDeleteColumn: repo.SoftDeleteColumn,
+			Test:             repo.Test,
+		},
+		Model: ModelOf(s, tableName),
+	}
+
+	// generatedBy is the entry of every function, since the functions of registered generators are not validated
+	generatedBy := make(map[string]string)
+	for _, g := range generators {
+		section := g.Section()
+		sectionEntries, functionNames, err := repo.entries(section)
+		if err != nil {
+			diagnostics = append(diagnostics, diagnosticsOf(repo, "", 0, "", err)...)
+			continue
+		}
+
+		for i, e := range sectionEntries {
+			request.Section, request.Index, request.Entry = section, i, e
+			function, err := g.Generate(request)
+			if err != nil {
+				diagnostics = append(diagnostics, diagnosticsOf(repo, section, i, functionNames[i], err)...)
+				continue
+			}
+			if label, ok := generatedBy[function.Name]; ok {
+				diagnostics = append(diagnostics, diagnosticsOf(repo, section, i, function.Name,
+					fmt.Errorf("function %s is also generated by %s", function.Name, label))...)
+				continue
+			}
+			generatedBy[function.Name] = fmt.Sprintf("%s[%d]", section, i)
+
+			functions = append(functions, function)
+			if function.Query != "" {
+				statementList = append(statementList, Statement{FunctionName: function.Name, Query: function.Query})
+				entries = append(entries, entry{section: section, index: i})
+			}
+		}
+	}
+
+	if verifier != nil {
+		if err := verifier.Verify(repo, statementList); err != nil {
+			var statementErrors StatementErrors
+			if !errors.As(err, &statementErrors) {
+				return nil, append(diagnostics, diagnosticsOf(repo, "", 0, "", err)...)
+			}
+
+			for _, statementError := range statementErrors {
+				e := entries[statementError.Index]
+				diagnostics = append(diagnostics, diagnosticsOf(repo, e.section, e.index,
+					statementList[statementError.Index].FunctionName, statementError.Err)...)
+			}
 		}
 	}
 
-	// Insert
-	for _, insert := range repo.Insert {
-		function, signature := BuildInsertFunction(
-			s,
-			repo.Dialect,
-			tableName,
-			insert.Fields,
-			insert.WithObject,
-			insert.FunctionName,
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	// the imports of the functions are added to the imports of the struct, and the ones that are not used are
+	// removed by the formatting
+	imports := make(map[string]string, len(s.Imports))
+	for path, name := range s.Imports {
+		imports[path] = name
+	}
+	var signatureList, functionList, testList []string
+	var repoSections, testSections []Section
+	for _, function := range functions {
+		for _, path := range function.Imports {
+			if _, ok := imports[path]; !ok {
+				imports[path] = internalStruct.ImportName(path)
+			}
+		}
+
+		signatureList = append(signatureList, function.Signature)
+		functionList = append(functionList, function.Source)
+		repoSections = append(repoSections,
+			Section{Name: "function " + function.Name, Source: function.Source},
+			Section{Name: "signature of function " + function.Name, Source: function.Signature},
 		)
-		functionList = append(functionList, function)
-		signatureList = append(signatureList, signature)
+		if function.Test != "" {
+			testList = append(testList, function.Test)
+			testSections = append(testSections, Section{Name: "test of function " + function.Name, Source: function.Test})
+		}
 	}
 
-	repoTemplate := BuildRepository(signatureList, functionList, repo.PackageName, s.TableName, s.Name)
+	repoTemplate, err := BuildRepository(tmpl, signatureList, functionList, repo.PackageName, s.TableName, s.Name, imports)
+	if err != nil {
+		return nil, Diagnostics(diagnosticsOf(repo, "", 0, "", err))
+	}
 
-	err = exportRepository(repoTemplate, repo.Destination)
+	repoTemplate, regionSections, err := preserveRegions(repo.Destination, repoTemplate)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("Error in writeFile: %s", err.Error()))
-		return err
+		return nil, Diagnostics(diagnosticsOf(repo, "", 0, "", err))
 	}
+	repoSections = append(repoSections, regionSections...)
 
-	err = linter(repo.Destination)
+	repoTemplate, err = FormatSource(repo.Destination, repoTemplate, repoSections)
 	if err != nil {
-		err = errors.New(fmt.Sprintf("Error in linter: %s", err.Error()))
-		return err
+		return nil, Diagnostics(diagnosticsOf(repo, "", 0, "", fmt.Errorf("error in formatting: %w", err)))
 	}
 
-	// TODO: add tests
+	files := []File{{Path: repo.Destination, Content: repoTemplate}}
 
-	return nil
-}
+	if repo.Test {
+		testDestination := strings.TrimSuffix(repo.Destination, ".go") + "_test.go"
+		testTemplate, err := BuildTestFile(tmpl, testList, repo.PackageName, s.Name, repo.Dialect, imports)
+		if err != nil {
+			return nil, Diagnostics(diagnosticsOf(repo, "", 0, "", err))
+		}
 
-func linter(dst string) error {
-	cmd := exec.Command("goimports", "-w", dst)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		err = errors.New(fmt.Sprintf("Error in goimports: %s", err.Error()))
-		return err
-	}
+		testTemplate, regionSections, err := preserveRegions(testDestination, testTemplate)
+		if err != nil {
+			return nil, Diagnostics(diagnosticsOf(repo, "", 0, "", err))
+		}
+		testSections = append(testSections, regionSections...)
 
-	cmd = exec.Command("gofmt", "-s", dst)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		err = errors.New(fmt.Sprintf("Error in gofmt: %s", err.Error()))
-		return err
+		testTemplate, err = FormatSource(testDestination, testTemplate, testSections)
+		if err != nil {
+			return nil, Diagnostics(diagnosticsOf(repo, "", 0, "",
+				fmt.Errorf("error in formatting %s: %w", testDestination, err)))
+		}
+
+		files = append(files, File{Path: testDestination, Content: testTemplate})
 	}
 
-	return nil
+	return files, nil
 }
 
 func exportRepository(content, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		err = errors.New(fmt.Sprintf("Error in creating directory: %s", err.Error()))
+		return err
+	}
+
 	f, err := os.Create(dst)
 
 	if err != nil {
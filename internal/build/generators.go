@@ -0,0 +1,242 @@
+package build
+
+import (
+	"fmt"
+
+	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// builtinGenerators returns the generators of the sections of Repo, in the order that their functions are generated.
+// They generate the functions of the struct by the templates.
+func builtinGenerators(tmpl *templates.Set, s *internalStruct.Structure) []generator.Generator {
+	return []generator.Generator{
+		selectGenerator{tmpl: tmpl, s: s},
+		insertGenerator{tmpl: tmpl, s: s},
+		updateGenerator{tmpl: tmpl, s: s},
+		deleteGenerator{tmpl: tmpl, s: s},
+	}
+}
+
+// functionOf returns the result of the Build functions as a generated function.
+func functionOf(function, signature, test string, statement Statement, err error) (generator.Function, error) {
+	if err != nil {
+		return generator.Function{}, err
+	}
+
+	return generator.Function{
+		Name:      statement.FunctionName,
+		Signature: signature,
+		Source:    function,
+		Test:      test,
+		Query:     statement.Query,
+	}, nil
+}
+
+// selectGenerator generates the get and select functions.
+type selectGenerator struct {
+	tmpl *templates.Set
+	s    *internalStruct.Structure
+}
+
+func (g selectGenerator) Section() string {
+	return "select"
+}
+
+func (g selectGenerator) Generate(request generator.Request) (generator.Function, error) {
+	var r Select
+	if err := request.Decode(&r); err != nil {
+		return generator.Function{}, err
+	}
+
+	build := BuildSelectFunction
+	switch r.Type {
+	case SelectTypeGet:
+		build = BuildGetFunction
+	case SelectTypeSelect:
+	default:
+		return generator.Function{}, &FieldError{
+			Field:   "type",
+			Value:   string(r.Type),
+			Message: fmt.Sprintf("invalid select type %q", r.Type),
+		}
+	}
+
+	return functionOf(build(
+		g.tmpl,
+		g.s,
+		DialectType(request.Repository.Dialect),
+		request.Model.TableName,
+		r.Fields,
+		r.WhereConditions,
+		r.AggregateFields,
+		&r.OrderBy,
+		&r.OrderType,
+		&r.Limit,
+		r.GroupBy,
+		r.JoinFields,
+		request.Repository.SoftDeleteColumn,
+		r.FunctionName,
+	))
+}
+
+// insertGenerator generates the insert functions, of a row or of multiple rows.
+type insertGenerator struct {
+	tmpl *templates.Set
+	s    *internalStruct.Structure
+}
+
+func (g insertGenerator) Section() string {
+	return "insert"
+}
+
+func (g insertGenerator) Generate(request generator.Request) (generator.Function, error) {
+	var insert Insert
+	if err := request.Decode(&insert); err != nil {
+		return generator.Function{}, err
+	}
+
+	if insert.Bulk {
+		return functionOf(BuildBulkInsertFunction(
+			g.tmpl,
+			g.s,
+			DialectType(request.Repository.Dialect),
+			request.Model.TableName,
+			insert.Fields,
+			insert.OnConflict,
+			insert.BatchSize,
+			insert.FunctionName,
+		))
+	}
+
+	return functionOf(BuildInsertFunction(
+		g.tmpl,
+		g.s,
+		DialectType(request.Repository.Dialect),
+		request.Model.TableName,
+		insert.Fields,
+		insert.WithObject,
+		insert.OnConflict,
+		insert.FunctionName,
+	))
+}
+
+// updateGenerator generates the update functions.
+type updateGenerator struct {
+	tmpl *templates.Set
+	s    *internalStruct.Structure
+}
+
+func (g updateGenerator) Section() string {
+	return "update"
+}
+
+func (g updateGenerator) Generate(request generator.Request) (generator.Function, error) {
+	var update Update
+	if err := request.Decode(&update); err != nil {
+		return generator.Function{}, err
+	}
+
+	return functionOf(BuildUpdateFunction(
+		g.tmpl,
+		g.s,
+		DialectType(request.Repository.Dialect),
+		request.Model.TableName,
+		update.Fields,
+		update.WhereConditions,
+		update.WithObject,
+		update.FunctionName,
+	))
+}
+
+// deleteGenerator generates the delete functions, which are updates of the soft delete column if the repository
+// has one.
+type deleteGenerator struct {
+	tmpl *templates.Set
+	s    *internalStruct.Structure
+}
+
+func (g deleteGenerator) Section() string {
+	return "delete"
+}
+
+func (g deleteGenerator) Generate(request generator.Request) (generator.Function, error) {
+	var del Delete
+	if err := request.Decode(&del); err != nil {
+		return generator.Function{}, err
+	}
+
+	return functionOf(BuildDeleteFunction(
+		g.tmpl,
+		g.s,
+		DialectType(request.Repository.Dialect),
+		request.Model.TableName,
+		del.WhereConditions,
+		request.Repository.SoftDeleteColumn,
+		del.AllRows,
+		del.FunctionName,
+	))
+}
+
+// entries returns the entries of a section of the repository, and the names of their custom functions.
+func (r Repo) entries(section string) ([]interface{}, []string, error) {
+	var entries []interface{}
+	var functionNames []string
+	switch section {
+	case "select":
+		for _, sel := range r.Select {
+			entries, functionNames = append(entries, sel), append(functionNames, sel.FunctionName)
+		}
+	case "insert":
+		for _, insert := range r.Insert {
+			entries, functionNames = append(entries, insert), append(functionNames, insert.FunctionName)
+		}
+	case "update":
+		for _, update := range r.Update {
+			entries, functionNames = append(entries, update), append(functionNames, update.FunctionName)
+		}
+	case "delete":
+		for _, del := range r.Delete {
+			entries, functionNames = append(entries, del), append(functionNames, del.FunctionName)
+		}
+	default:
+		value := r.Sections[section]
+		if value == nil {
+			return nil, nil, nil
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, nil, &FieldError{Field: section, Message: "must be a list"}
+		}
+		for _, item := range items {
+			functionName := ""
+			if entry, ok := item.(map[string]interface{}); ok {
+				functionName, _ = entry["function_name"].(string)
+			}
+			entries, functionNames = append(entries, item), append(functionNames, functionName)
+		}
+	}
+
+	return entries, functionNames, nil
+}
+
+// ModelOf returns the model of the generators of a struct, whose table is the table of the repository.
+func ModelOf(s *internalStruct.Structure, tableName string) generator.Model {
+	model := generator.Model{
+		Name:        s.Name,
+		PackageName: s.PackageName,
+		TableName:   tableName,
+		Imports:     s.Imports,
+	}
+	for _, field := range s.Fields {
+		model.Fields = append(model.Fields, generator.Field{
+			Name:   field.Name,
+			Type:   field.Type,
+			Column: field.DBFlag,
+			Tag:    string(field.Tags),
+		})
+	}
+
+	return model
+}
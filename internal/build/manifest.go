@@ -0,0 +1,441 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+)
+
+// Manifest is a list of repositories that are generated together.
+type Manifest struct {
+	Repos []Repo `yaml:"repositories"`
+
+	// path is the path of the manifest file, and nodes are the yaml nodes of the repositories, that have their
+	// line numbers
+	path  string
+	nodes []*yaml.Node
+	// positions are the positions of the repositories that are not read from a manifest file
+	positions []Position
+}
+
+// Position is where a repository is declared outside of a manifest file, e.g. by the ct: comments of a struct.
+type Position struct {
+	// File is the file of the declaration, and Line is the line of the repository in it
+	File string
+	Line int
+	// Entries are the lines of the entries of the repository by their section, e.g. "select"
+	Entries map[string][]int
+}
+
+// NewManifest returns a manifest of repositories that are not read from a manifest file. The diagnostics of a
+// repository are located at its position, which has the same index.
+func NewManifest(repos []Repo, positions []Position) *Manifest {
+	return &Manifest{Repos: repos, positions: positions}
+}
+
+// Defaults are the values of the repositories that their manifest does not set, e.g. the ones of the project
+// configuration.
+type Defaults struct {
+	Dialect     DialectType
+	PackageName string
+	DBLibrary   string
+	TableNaming internalStruct.TableNaming
+	// Test is nil if the test generation is not set
+	Test *bool
+}
+
+// repo returns a repository that has the defaults, which the values of a manifest are decoded on.
+func (d Defaults) repo() Repo {
+	repo := Repo{
+		Dialect:     d.Dialect,
+		PackageName: d.PackageName,
+		DBLibrary:   d.DBLibrary,
+		TableNaming: d.TableNaming,
+	}
+	if d.Test != nil {
+		repo.Test = *d.Test
+	}
+	return repo
+}
+
+// sets reports whether the defaults set the yaml key of a repository.
+func (d Defaults) sets(key string) bool {
+	switch key {
+	case "dialect":
+		return d.Dialect != ""
+	case "package_name":
+		return d.PackageName != ""
+	case "db_library":
+		return d.DBLibrary != ""
+	case "table_naming":
+		return d.TableNaming != ""
+	case "test":
+		return d.Test != nil
+	}
+	return false
+}
+
+// LoadManifest reads a manifest file. The file can contain a single repository, a list of repositories under
+// the `repositories` key, or several yaml documents of each kind. The values that a repository does not set are
+// taken from the defaults.
+func LoadManifest(path string, defaults Defaults) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseManifest(path, content, defaults)
+}
+
+// ParseManifest parses the content of a manifest file, like LoadManifest reads it. The path is the path of the
+// manifest in the diagnostics of the manifest.
+func ParseManifest(path string, content []byte, defaults Defaults) (*Manifest, error) {
+	manifest := &Manifest{path: path}
+
+	d := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		if err := d.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		if isRepositoryList(&document) {
+			var m Manifest
+			if err := document.Decode(&m); err != nil {
+				return nil, err
+			}
+
+			// the repositories are decoded again on the defaults
+			nodes := repositoryNodes(&document)
+			for _, node := range nodes {
+				repo := defaults.repo()
+				if err := node.Decode(&repo); err != nil {
+					return nil, err
+				}
+				manifest.Repos = append(manifest.Repos, repo)
+			}
+			manifest.nodes = append(manifest.nodes, nodes...)
+		} else {
+			repo := defaults.repo()
+			if err := document.Decode(&repo); err != nil {
+				return nil, err
+			}
+			manifest.Repos = append(manifest.Repos, repo)
+			manifest.nodes = append(manifest.nodes, documentRoot(&document))
+		}
+	}
+
+	if len(manifest.Repos) == 0 {
+		return nil, fmt.Errorf("no repository found in manifest %s", path)
+	}
+
+	return manifest, nil
+}
+
+// RepoNode returns the yaml node of the repository at the index of Repos, or nil if the manifest is not loaded from
+// a file.
+func (m *Manifest) RepoNode(index int) *yaml.Node {
+	if index < 0 || index >= len(m.nodes) {
+		return nil
+	}
+	return m.nodes[index]
+}
+
+// Subset returns a manifest of the repositories at indexes of Repos, whose diagnostics have their lines in the
+// manifest, e.g. to generate the repositories whose sources change again.
+func (m *Manifest) Subset(indexes ...int) *Manifest {
+	subset := &Manifest{path: m.path}
+	for _, index := range indexes {
+		subset.Repos = append(subset.Repos, m.Repos[index])
+		if index < len(m.nodes) {
+			subset.nodes = append(subset.nodes, m.nodes[index])
+		}
+		if index < len(m.positions) {
+			subset.positions = append(subset.positions, m.positions[index])
+		}
+	}
+
+	return subset
+}
+
+// WithRepos returns a manifest of repos whose diagnostics have the lines of the repositories at the same indexes of
+// Repos, e.g. the repositories of the manifest after they are changed.
+func (m *Manifest) WithRepos(repos []Repo) *Manifest {
+	return &Manifest{Repos: repos, path: m.path, nodes: m.nodes, positions: m.positions}
+}
+
+// repositoryNodes returns the items of the `repositories` list of the document.
+func repositoryNodes(document *yaml.Node) []*yaml.Node {
+	root := documentRoot(document)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "repositories" {
+			return root.Content[i+1].Content
+		}
+	}
+	return nil
+}
+
+// isRepositoryList reports whether the document has a `repositories` key at its top level.
+func isRepositoryList(document *yaml.Node) bool {
+	root := documentRoot(document)
+	if root.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value == "repositories" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func documentRoot(document *yaml.Node) *yaml.Node {
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+	return document
+}
+
+// SelectRepos returns the repositories that match a comma-separated tag filter, e.g. "billing,!legacy".
+// A repository matches if it has none of the negated tags and, when the filter has other tags, at least one of them.
+// An empty filter matches every repository.
+func (m *Manifest) SelectRepos(filter string) []Repo {
+	var repos []Repo
+	for _, index := range m.selected(filter) {
+		repos = append(repos, m.Repos[index])
+	}
+
+	return repos
+}
+
+// selected returns the indexes of the repositories that match a tag filter.
+func (m *Manifest) selected(filter string) []int {
+	var included, excluded []string
+	for _, tag := range strings.Split(filter, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		if strings.HasPrefix(tag, "!") {
+			excluded = append(excluded, strings.TrimSpace(tag[1:]))
+		} else {
+			included = append(included, tag)
+		}
+	}
+
+	var indexes []int
+	for i, repo := range m.Repos {
+		if repo.hasAnyTag(excluded) {
+			continue
+		}
+		if len(included) > 0 && !repo.hasAnyTag(included) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+
+	return indexes
+}
+
+// Apply generates the repositories that match a tag filter. Every repository is rendered before any of them is
+// written, so the problems of all of them are returned together as Diagnostics with their lines in the manifest. If
+// tmpl is nil, the repositories are generated by DefaultTemplates.
+func (m *Manifest) Apply(filter string, verifier Verifier, tmpl *templates.Set) error {
+	files, err := m.Render(filter, verifier, tmpl)
+	if err != nil {
+		return err
+	}
+
+	return WriteFiles(files)
+}
+
+// Render returns the files of the repositories that match a tag filter without writing them. The problems of all
+// the repositories are returned together as Diagnostics, sorted by their lines in the manifest. If tmpl is nil, the
+// repositories are rendered by DefaultTemplates.
+func (m *Manifest) Render(filter string, verifier Verifier, tmpl *templates.Set) ([]File, error) {
+	indexes := m.selected(filter)
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no repository matches tags %q", filter)
+	}
+
+	var files []File
+	var diagnostics Diagnostics
+	for _, index := range indexes {
+		repoFiles, err := Render(m.Repos[index], verifier, tmpl)
+		if err != nil {
+			var repoDiagnostics Diagnostics
+			if !errors.As(err, &repoDiagnostics) {
+				repoDiagnostics = diagnosticsOf(m.Repos[index], "", 0, "", err)
+			}
+			for _, d := range repoDiagnostics {
+				diagnostics = append(diagnostics, m.locate(index, d))
+			}
+			continue
+		}
+
+		files = append(files, repoFiles...)
+	}
+
+	if len(diagnostics) > 0 {
+		sort.SliceStable(diagnostics, func(i, j int) bool {
+			if diagnostics[i].Manifest != diagnostics[j].Manifest {
+				return diagnostics[i].Manifest < diagnostics[j].Manifest
+			}
+			return diagnostics[i].Line < diagnostics[j].Line
+		})
+		return nil, diagnostics
+	}
+
+	return files, nil
+}
+
+// locate sets the manifest and the line of a diagnostic of the repository at the index of Repos. The line is the
+// line of the value that has the problem, or the closest node that the manifest has, e.g. the line of the entry.
+func (m *Manifest) locate(index int, d Diagnostic) Diagnostic {
+	if index >= 0 && index < len(m.positions) {
+		position := m.positions[index]
+		d.Manifest, d.Line = position.File, position.Line
+		if lines := position.Entries[d.section]; d.section != "" && d.index < len(lines) {
+			d.Line = lines[d.index]
+		}
+		return d
+	}
+
+	d.Manifest = m.path
+
+	node := m.RepoNode(index)
+	if node == nil {
+		return d
+	}
+
+	if d.section != "" {
+		if entry := sequenceItem(mappingValue(node, d.section), d.index); entry != nil {
+			node = entry
+		}
+	}
+	if d.Field != "" {
+		if field := fieldValue(node, d.Field); field != nil {
+			node = field
+			if value := findScalar(field, d.value); value != nil {
+				node = value
+			}
+		}
+	}
+
+	d.Line = node.Line
+	return d
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil if the node does not have it.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// fieldValue returns the value of a field of a diagnostic under a node, e.g. "join_fields[1].function", or nil if
+// the node does not have it.
+func fieldValue(node *yaml.Node, field string) *yaml.Node {
+	for _, part := range strings.Split(field, ".") {
+		key, indexes, _ := strings.Cut(part, "[")
+		node = mappingValue(node, key)
+		if indexes == "" {
+			continue
+		}
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			i, err := strconv.Atoi(index)
+			if err != nil {
+				return nil
+			}
+			node = sequenceItem(node, i)
+		}
+	}
+	return node
+}
+
+// sequenceItem returns an item of a sequence node, or nil if the node does not have it.
+func sequenceItem(node *yaml.Node, index int) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+		return nil
+	}
+	return resolveAlias(node.Content[index])
+}
+
+// findScalar returns the first scalar node under a node that has the value, or nil if value is empty or not found.
+func findScalar(node *yaml.Node, value string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || value == "" {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.Value == value {
+			return node
+		}
+		return nil
+	}
+
+	for i, child := range node.Content {
+		// keys of mappings are not values
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		if found := findScalar(child, value); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// AllTags returns the tags of the repository, including the single `tag` field.
+func (r Repo) AllTags() []string {
+	tags := r.Tags
+	if r.Tag != "" {
+		tags = append([]string{r.Tag}, tags...)
+	}
+
+	return tags
+}
+
+func (r Repo) hasAnyTag(tags []string) bool {
+	for _, tag := range r.AllTags() {
+		for _, t := range tags {
+			if tag == t {
+				return true
+			}
+		}
+	}
+
+	return false
+}
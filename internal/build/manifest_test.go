@@ -0,0 +1,232 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		defaults     Defaults
+		destinations []string
+		dialects     []DialectType
+		err          string
+	}{
+		{
+			name: "single repository",
+			content: `
+source: models/user.go
+destination: repository/user.go
+dialect: mysql
+`,
+			destinations: []string{"repository/user.go"},
+			dialects:     []DialectType{MySQL},
+		},
+		{
+			name: "list of repositories",
+			content: `
+repositories:
+  - source: models/user.go
+    destination: repository/user.go
+    dialect: postgres
+  - source: models/role.go
+    destination: repository/role.go
+    dialect: sqlite3
+`,
+			destinations: []string{"repository/user.go", "repository/role.go"},
+			dialects:     []DialectType{Postgres, SQLite3},
+		},
+		{
+			name: "several documents",
+			content: `
+source: models/user.go
+destination: repository/user.go
+dialect: mysql
+---
+repositories:
+  - source: models/role.go
+    destination: repository/role.go
+    dialect: mysql
+`,
+			destinations: []string{"repository/user.go", "repository/role.go"},
+			dialects:     []DialectType{MySQL, MySQL},
+		},
+		{
+			name: "defaults",
+			content: `
+repositories:
+  - source: models/user.go
+    destination: repository/user.go
+  - source: models/role.go
+    destination: repository/role.go
+    dialect: mysql
+`,
+			defaults:     Defaults{Dialect: Postgres},
+			destinations: []string{"repository/user.go", "repository/role.go"},
+			dialects:     []DialectType{Postgres, MySQL},
+		},
+		{
+			name:    "empty",
+			content: "",
+			err:     "no repository found in manifest manifest.yaml",
+		},
+		{
+			name: "syntax error",
+			content: `
+repositories:
+  - source: [models/user.go
+`,
+			err: "did not find expected",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest, err := ParseManifest("manifest.yaml", []byte(test.content), test.defaults)
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			var destinations []string
+			var dialects []DialectType
+			for _, repo := range manifest.Repos {
+				destinations = append(destinations, repo.Destination)
+				dialects = append(dialects, repo.Dialect)
+			}
+			assert.Equal(t, test.destinations, destinations)
+			assert.Equal(t, test.dialects, dialects)
+		})
+	}
+}
+
+func TestManifestLocate(t *testing.T) {
+	content := `repositories:
+  - source: models/user.go
+    destination: repository/user.go
+    dialect: mysql
+  - source: models/role.go
+    destination: repository/role.go
+    dialect: mysql
+    select:
+      - type: get
+        where_conditions:
+          - column: id
+            operator: equal
+    insert:
+      - fields: [name, emial]
+      - fields:
+          - name
+          - title
+`
+	manifest, err := ParseManifest("manifest.yaml", []byte(content), Defaults{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		index   int
+		section string
+		entry   int
+		err     error
+		line    int
+	}{
+		{
+			name:  "repository",
+			index: 0,
+			err:   &FieldError{Field: "source", Message: "file is not found"},
+			line:  2,
+		},
+		{
+			name:  "repository without field",
+			index: 1,
+			err:   &FieldError{Message: "struct is not found"},
+			line:  5,
+		},
+		{
+			name:    "entry",
+			index:   1,
+			section: "select",
+			err:     &FieldError{Message: "select is not valid"},
+			line:    9,
+		},
+		{
+			name:    "field of entry",
+			index:   1,
+			section: "select",
+			err:     &FieldError{Field: "where_conditions", Message: "column is not found"},
+			line:    11,
+		},
+		{
+			name:    "value of inline list",
+			index:   1,
+			section: "insert",
+			err:     &FieldError{Field: "fields", Value: "emial", Message: "field emial is not found"},
+			line:    14,
+		},
+		{
+			name:    "value of block list",
+			index:   1,
+			section: "insert",
+			entry:   1,
+			err:     &FieldError{Field: "fields", Value: "title", Message: "field title is not found"},
+			line:    17,
+		},
+		{
+			name:    "field that the entry does not have",
+			index:   1,
+			section: "insert",
+			entry:   1,
+			err:     &FieldError{Field: "on_conflict", Message: "on_conflict is not valid"},
+			line:    15,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diagnostics := diagnosticsOf(manifest.Repos[test.index], test.section, test.entry, "", test.err)
+			require.Len(t, diagnostics, 1)
+
+			d := manifest.locate(test.index, diagnostics[0])
+			assert.Equal(t, "manifest.yaml", d.Manifest)
+			assert.Equal(t, test.line, d.Line)
+		})
+	}
+}
+
+func TestSelectRepos(t *testing.T) {
+	manifest := &Manifest{Repos: []Repo{
+		{Destination: "user.go", Tags: []string{"core", "billing"}},
+		{Destination: "role.go", Tag: "core"},
+		{Destination: "invoice.go", Tags: []string{"billing", "legacy"}},
+		{Destination: "log.go"},
+	}}
+
+	tests := []struct {
+		filter       string
+		destinations []string
+	}{
+		{filter: "", destinations: []string{"user.go", "role.go", "invoice.go", "log.go"}},
+		{filter: "core", destinations: []string{"user.go", "role.go"}},
+		{filter: "billing,core", destinations: []string{"user.go", "role.go", "invoice.go"}},
+		{filter: "!legacy", destinations: []string{"user.go", "role.go", "log.go"}},
+		{filter: "billing, !legacy", destinations: []string{"user.go"}},
+		{filter: " ! core ", destinations: []string{"invoice.go", "log.go"}},
+		{filter: "unknown", destinations: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			var destinations []string
+			for _, repo := range manifest.SelectRepos(test.filter) {
+				destinations = append(destinations, repo.Destination)
+			}
+			assert.Equal(t, test.destinations, destinations)
+		})
+	}
+}
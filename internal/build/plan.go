@@ -0,0 +1,74 @@
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Change is a generated file that differs from the file on disk.
+type Change struct {
+	Path string
+	// Exists reports whether the file is on disk, and Current is its content
+	Exists  bool
+	Current string
+	Content string
+}
+
+// Plan returns the generated files that differ from the files on disk, in the order of the files.
+func Plan(files []File) ([]Change, error) {
+	var changes []Change
+	for _, file := range files {
+		current, err := os.ReadFile(file.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		exists := err == nil
+		if exists && string(current) == file.Content {
+			continue
+		}
+		changes = append(changes, Change{
+			Path:    file.Path,
+			Exists:  exists,
+			Current: string(current),
+			Content: file.Content,
+		})
+	}
+
+	return changes, nil
+}
+
+// UnifiedDiff returns the change as a unified diff from the file on disk to the generated file, like `git diff` does.
+func (c Change) UnifiedDiff() (string, error) {
+	path := filepath.ToSlash(filepath.Clean(c.Path))
+	fromFile, toFile := path, path
+	if !filepath.IsAbs(c.Path) {
+		fromFile, toFile = "a/"+path, "b/"+path
+	}
+	if !c.Exists {
+		fromFile = "/dev/null"
+	}
+
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(c.Current),
+		B:        splitLines(c.Content),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+}
+
+// splitLines splits a file into lines that keep their line endings, as difflib expects.
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n"
+	return lines
+}
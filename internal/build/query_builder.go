@@ -1,13 +1,18 @@
 package build
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/doug-martin/goqu/v9"
-	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
-	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
-	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/doug-martin/goqu/v9/dialect/mysql"
+	"github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/doug-martin/goqu/v9/dialect/sqlite3"
 	_ "github.com/doug-martin/goqu/v9/dialect/sqlserver"
+	"github.com/doug-martin/goqu/v9/exp"
+
+	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
 )
 
 type OrderType string
@@ -66,6 +71,19 @@ const (
 	SQLServer DialectType = "sqlserver"
 )
 
+// maxPlaceholders is the maximum number of placeholders that a query can have in each dialect
+var maxPlaceholders = map[DialectType]int{
+	MySQL:     65535,
+	Postgres:  65535,
+	SQLite3:   999,
+	SQLServer: 2100,
+}
+
+// maxRows is the maximum number of rows that an insert query can have in each dialect
+var maxRows = map[DialectType]int{
+	SQLServer: 1000,
+}
+
 var setAggregate = map[string]struct{}{
 	"COUNT": struct{}{},
 	"SUM":   struct{}{},
@@ -76,6 +94,19 @@ var setAggregate = map[string]struct{}{
 	"LAST":  struct{}{},
 }
 
+var setOperator = map[OperatorType]struct{}{
+	OperatorTypeEqual:     {},
+	OperatorTypeNotEqual:  {},
+	OperatorTypeIn:        {},
+	OperatorTypeNotIn:     {},
+	OperatorTypeGt:        {},
+	OperatorTypeGte:       {},
+	OperatorTypeLt:        {},
+	OperatorTypeLte:       {},
+	OperatorTypeIsNull:    {},
+	OperatorTypeIsNotNull: {},
+}
+
 // AggregateField is a struct for aggregate field
 type AggregateField struct {
 	Function string `yaml:"function"`
@@ -111,13 +142,47 @@ type Select struct {
 	GroupBy         []string         `yaml:"group_by"`
 }
 
+type ConflictActionType string
+
+const (
+	ConflictActionDoNothing ConflictActionType = "do_nothing"
+	ConflictActionUpdate    ConflictActionType = "update"
+)
+
+// OnConflict is a struct for resolving conflicts of insert functions
+type OnConflict struct {
+	Columns       []string           `yaml:"columns"`
+	Action        ConflictActionType `yaml:"action"`
+	UpdateColumns []string           `yaml:"update_columns"`
+}
+
 type Insert struct {
-	Fields       []string `yaml:"fields"`
-	FunctionName string   `yaml:"function_name"`
-	WithObject   bool     `yaml:"with_object"`
+	Fields       []string    `yaml:"fields"`
+	FunctionName string      `yaml:"function_name"`
+	WithObject   bool        `yaml:"with_object"`
+	OnConflict   *OnConflict `yaml:"on_conflict"`
+	Bulk         bool        `yaml:"bulk"`
+	BatchSize    int         `yaml:"batch_size"`
+}
+
+type Update struct {
+	Fields          []string         `yaml:"fields"`
+	WhereConditions []WhereCondition `yaml:"where_conditions"`
+	FunctionName    string           `yaml:"function_name"`
+	WithObject      bool             `yaml:"with_object"`
+}
+
+type Delete struct {
+	WhereConditions []WhereCondition `yaml:"where_conditions"`
+	FunctionName    string           `yaml:"function_name"`
+	// AllRows generates a function that deletes every row of the table, which a delete without where conditions
+	// needs so it is not generated by mistake
+	AllRows bool `yaml:"all_rows"`
 }
 
 type Repo struct {
+	Tag         string      `yaml:"tag"`
+	Tags        []string    `yaml:"tags"`
 	Source      string      `yaml:"source"`
 	Destination string      `yaml:"destination"`
 	Dialect     DialectType `yaml:"dialect"`
@@ -128,6 +193,32 @@ type Repo struct {
 	Test        bool        `yaml:"test"`
 	Select      []Select    `yaml:"select"`
 	Insert      []Insert    `yaml:"insert"`
+	Update      []Update    `yaml:"update"`
+	Delete      []Delete    `yaml:"delete"`
+
+	// SoftDeleteColumn turns delete functions into updates that set the column to
+	// the current timestamp and makes select functions skip the deleted rows.
+	SoftDeleteColumn string `yaml:"soft_delete_column"`
+
+	// TableNaming names the table when TableName is not set. By default, the table is named in the snake case of
+	// the struct name.
+	TableNaming internalStruct.TableNaming `yaml:"table_naming"`
+
+	// Sections are the other keys of the repository, which are the sections of the generators that are registered
+	// by generator.Register, e.g. archive
+	Sections map[string]interface{} `yaml:",inline"`
+}
+
+// TableNameOf returns the table of the repository of a struct, which is named by the table naming of the repository
+// if the manifest does not set it.
+func (r Repo) TableNameOf(s *internalStruct.Structure) string {
+	if r.TableName != "" {
+		return r.TableName
+	}
+	if r.TableNaming != "" {
+		return r.TableNaming.TableName(s.Name)
+	}
+	return s.TableName
 }
 
 // BuildSelectQuery builds a select query
@@ -142,9 +233,10 @@ func BuildSelectQuery(
 	limit *uint,
 	groupBy []interface{},
 	join []JoinField,
-) string {
+	softDeleteColumn string,
+) (string, []interface{}, error) {
 	d := goqu.Dialect(string(dialect))
-	ds := d.From(table)
+	ds := d.From(table).Prepared(true)
 
 	// Aggregate: e.g. COUNT, SUM, MIN, MAX, AVG, FIRST, LAST
 	aggregateExpressions := make([]interface{}, 0)
@@ -152,7 +244,11 @@ func BuildSelectQuery(
 		for _, agg := range aggregate {
 			_, ok := setAggregate[strings.ToUpper(agg.Function)]
 			if !ok {
-				panic("invalid aggregate function: " + agg.Function)
+				return "", nil, &FieldError{
+					Field:   "aggregate_fields",
+					Value:   agg.Function,
+					Message: fmt.Sprintf("invalid aggregate function %q", agg.Function),
+				}
 			}
 
 			switch strings.ToUpper(agg.Function) {
@@ -183,33 +279,15 @@ func BuildSelectQuery(
 	}
 
 	// Where
-	whereConditions := goqu.Ex{}
-	if len(where) > 0 {
-		for _, cond := range where {
-			switch cond.Operator {
-			case OperatorTypeEqual:
-				whereConditions[cond.Column] = 9999999999999999
-			case OperatorTypeNotEqual:
-				whereConditions[cond.Column] = goqu.Op{"neq": 9999999999999999}
-			case OperatorTypeIn:
-				whereConditions[cond.Column] = goqu.Op{"in": 9999999999999999}
-			case OperatorTypeNotIn:
-				whereConditions[cond.Column] = goqu.Op{"not_in": 9999999999999999}
-			case OperatorTypeGt:
-				whereConditions[cond.Column] = goqu.Op{"gt": 9999999999999999}
-			case OperatorTypeGte:
-				whereConditions[cond.Column] = goqu.Op{"gte": 9999999999999999}
-			case OperatorTypeLt:
-				whereConditions[cond.Column] = goqu.Op{"lt": 9999999999999999}
-			case OperatorTypeLte:
-				whereConditions[cond.Column] = goqu.Op{"lte": 9999999999999999}
-			case OperatorTypeIsNull:
-				whereConditions[cond.Column] = goqu.Op{"is_null": true}
-			case OperatorTypeIsNotNull:
-				whereConditions[cond.Column] = goqu.Op{"is_null": false}
-			}
-		}
-		ds = ds.Where(whereConditions)
+	whereExpressions, err := buildWhereExpressions(where, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if softDeleteColumn != "" {
+		whereExpressions = append(whereExpressions, goqu.I(table+"."+softDeleteColumn).IsNull())
+	}
+	if len(whereExpressions) > 0 {
+		ds = ds.Where(whereExpressions...)
 	}
 
 	// Order By
@@ -233,46 +311,51 @@ func BuildSelectQuery(
 
 	// Join
 	for _, j := range join {
+		joinTable := j.Table
+		if j.As != "" {
+			joinTable = j.As
+		}
+
 		switch j.Function {
 		case JoinTypeJoin:
 			ds = ds.Join(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeFullOuter:
 			ds = ds.FullOuterJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeLeft:
 			ds = ds.LeftJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeRight:
 			ds = ds.RightJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeInner:
 			ds = ds.InnerJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeRightOuter:
 			ds = ds.RightOuterJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeLeftOuter:
 			ds = ds.LeftOuterJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeFull:
 			ds = ds.FullJoin(
 				goqu.T(j.Table).As(j.As),
-				goqu.On(goqu.Ex{table + "." + j.OnSource: j.Table + "." + j.OnJoin}),
+				goqu.On(goqu.I(table+"."+j.OnSource).Eq(goqu.I(joinTable+"."+j.OnJoin))),
 			)
 		case JoinTypeNatural:
 			ds = ds.NaturalJoin(
@@ -294,17 +377,22 @@ func BuildSelectQuery(
 			ds = ds.CrossJoin(
 				goqu.T(j.Table).As(j.As),
 			)
+		default:
+			return "", nil, &FieldError{
+				Field:   "join_fields",
+				Value:   string(j.Function),
+				Message: fmt.Sprintf("invalid join function %q of table %s", j.Function, j.Table),
+			}
 		}
 	}
 
 	// Build
-	query, _, _ := ds.ToSQL()
-
-	// Replace 9999999999999999 with "?"
-	query = strings.ReplaceAll(query, "'9999999999999999'", "?")
-	query = strings.ReplaceAll(query, "9999999999999999", "?")
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid select query of table %s: %w", table, err)
+	}
 
-	return query
+	return query, args, nil
 }
 
 // BuildUpdateQuery Building a query to update a table.
@@ -313,55 +401,38 @@ func BuildUpdateQuery(
 	table string,
 	fields []interface{},
 	where []WhereCondition,
-) string {
+	withObject bool,
+) (string, []interface{}, error) {
 	d := goqu.Dialect(string(dialect))
-	ds := d.Update(table)
+	ds := d.Update(table).Prepared(!withObject)
 
 	// Set
 	setRecords := make(goqu.Record, 0)
 	for _, f := range fields {
-		setRecords[f.(string)] = 9999999999999999
+		if withObject {
+			setRecords[f.(string)] = goqu.L(":" + f.(string))
+		} else {
+			setRecords[f.(string)] = setArgument(f.(string))
+		}
 	}
 	ds = ds.Set(setRecords)
 
 	// Where
-	whereConditions := goqu.Ex{}
 	if len(where) > 0 {
-		for _, cond := range where {
-			switch cond.Operator {
-			case OperatorTypeEqual:
-				whereConditions[cond.Column] = 9999999999999999
-			case OperatorTypeNotEqual:
-				whereConditions[cond.Column] = goqu.Op{"neq": 9999999999999999}
-			case OperatorTypeIn:
-				whereConditions[cond.Column] = goqu.Op{"in": 9999999999999999}
-			case OperatorTypeNotIn:
-				whereConditions[cond.Column] = goqu.Op{"not_in": 9999999999999999}
-			case OperatorTypeGt:
-				whereConditions[cond.Column] = goqu.Op{"gt": 9999999999999999}
-			case OperatorTypeGte:
-				whereConditions[cond.Column] = goqu.Op{"gte": 9999999999999999}
-			case OperatorTypeLt:
-				whereConditions[cond.Column] = goqu.Op{"lt": 9999999999999999}
-			case OperatorTypeLte:
-				whereConditions[cond.Column] = goqu.Op{"lte": 9999999999999999}
-			case OperatorTypeIsNull:
-				whereConditions[cond.Column] = goqu.Op{"is_null": true}
-			case OperatorTypeIsNotNull:
-				whereConditions[cond.Column] = goqu.Op{"is_null": false}
-			}
+		whereExpressions, err := buildWhereExpressions(where, withObject)
+		if err != nil {
+			return "", nil, err
 		}
-		ds = ds.Where(whereConditions)
+		ds = ds.Where(whereExpressions...)
 	}
 
 	// Build
-	query, _, _ := ds.ToSQL()
-
-	// Replace 9999999999999999 with "?"
-	query = strings.ReplaceAll(query, "'9999999999999999'", "?")
-	query = strings.ReplaceAll(query, "9999999999999999", "?")
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid update query of table %s: %w", table, err)
+	}
 
-	return query
+	return query, args, nil
 }
 
 // BuildInsertQuery build insert query
@@ -370,29 +441,394 @@ func BuildInsertQuery(
 	table string,
 	fields []string,
 	withObject bool,
-) string {
+	onConflict *OnConflict,
+) (string, []interface{}, error) {
 	d := goqu.Dialect(string(dialect))
-	ds := d.Insert(table)
+	if onConflict != nil && dialect != SQLServer {
+		d = goqu.Dialect(conflictDialect(dialect))
+	}
+	ds := d.Insert(table).Prepared(!withObject)
 
 	// Set
 	if len(fields) == 0 {
-		panic("insert fields is empty")
+		return "", nil, &FieldError{Field: "fields", Message: "insert fields is empty"}
 	}
 
-	setRecords := make(goqu.Record, 0)
-	if withObject {
-		for _, f := range fields {
-			setRecords[f] = ":" + f
+	columns := make([]interface{}, len(fields))
+	values := make([]interface{}, len(fields))
+	for i, f := range fields {
+		columns[i] = f
+		if withObject {
+			values[i] = goqu.L(":" + f)
+		} else {
+			values[i] = setArgument(f)
 		}
-	} else {
-		for _, f := range fields {
-			setRecords[f] = "?"
+	}
+	ds = ds.Cols(columns...).Vals(values)
+
+	if onConflict != nil {
+		if dialect == SQLServer {
+			return buildMergeQuery(table, fields, withObject, onConflict)
+		}
+
+		conflict, err := conflictExpression(dialect, fields, onConflict)
+		if err != nil {
+			return "", nil, err
 		}
+		ds = ds.OnConflict(conflict)
 	}
-	ds = ds.Rows(setRecords)
 
 	// Build
-	query, _, _ := ds.ToSQL()
+	query, args, err := ds.ToSQL()
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid insert query of table %s: %w", table, err)
+	}
+
+	return query, args, nil
+}
+
+// BuildBulkInsertQuery builds the parts of an insert query with multiple rows.
+// The query is prefix + rows joined by ", " + suffix, and each row has a placeholder for each field.
+func BuildBulkInsertQuery(
+	dialect DialectType,
+	table string,
+	fields []string,
+	onConflict *OnConflict,
+) (prefix, suffix string, err error) {
+	query, _, err := BuildInsertQuery(dialect, table, fields, false, onConflict)
+	if err != nil {
+		return "", "", err
+	}
+
+	row := BulkInsertRow(dialect, len(fields), 0)
+	index := strings.Index(query, row)
+	if index == -1 {
+		return "", "", fmt.Errorf("values not found in insert query: %s", query)
+	}
+
+	return query[:index], query[index+len(row):], nil
+}
+
+// BulkInsertRow returns the values of a row in a bulk insert query,
+// that its placeholders are numbered after the given number of arguments.
+func BulkInsertRow(dialect DialectType, fieldsCount int, argumentsCount int) string {
+	placeholders := make([]string, fieldsCount)
+	for i := range placeholders {
+		placeholders[i] = placeholder(dialect, argumentsCount+i+1)
+	}
+
+	return "(" + strings.Join(placeholders, ", ") + ")"
+}
+
+// BulkInsertBatchSize returns the number of rows that fit in one insert query of the dialect.
+// If batchSize is set and fits, it is returned as is.
+func BulkInsertBatchSize(dialect DialectType, fieldsCount int, batchSize int) int {
+	limit := maxPlaceholders[dialect] / fieldsCount
+	if rows, ok := maxRows[dialect]; ok && rows < limit {
+		limit = rows
+	}
+
+	if batchSize > 0 && batchSize < limit {
+		return batchSize
+	}
+
+	return limit
+}
+
+// errConflictColumns is the error of the conflict resolutions that need the conflict columns but do not have them
+var errConflictColumns = &FieldError{Field: "on_conflict", Message: "on conflict columns is empty"}
+
+// conflictDialects are the goqu dialects of the insert queries that resolve conflicts. goqu adds "INSERT IGNORE"
+// (MySQL) and "INSERT OR IGNORE" (SQLite) to every query with a conflict expression, which also ignores other
+// errors, so the dialects are registered again without the insert ignore syntax.
+var conflictDialects = map[DialectType]*goqu.SQLDialectOptions{
+	MySQL:    mysql.DialectOptions(),
+	Postgres: postgres.DialectOptions(),
+	SQLite3:  sqlite3.DialectOptions(),
+}
+
+func init() {
+	for dialect, options := range conflictDialects {
+		options.SupportsInsertIgnoreSyntax = false
+		if dialect == SQLite3 {
+			// the fragments of SQLite have spaces around them, which are doubled next to the conflict target
+			options.ConflictFragment = []byte(" ON CONFLICT")
+			options.ConflictDoNothingFragment = []byte(" DO NOTHING")
+		}
+		goqu.RegisterDialect(conflictDialect(dialect), options)
+	}
+}
+
+// conflictDialect returns the name of the goqu dialect of the insert queries of a dialect that resolve conflicts.
+func conflictDialect(dialect DialectType) string {
+	return "crafting-table-" + string(dialect)
+}
+
+// conflictExpression returns the goqu expression that resolves conflicts of an insert query.
+func conflictExpression(dialect DialectType, fields []string, onConflict *OnConflict) (exp.ConflictExpression, error) {
+	updateColumns := conflictUpdateColumns(fields, onConflict)
+
+	if dialect == MySQL {
+		// MySQL does not have "do nothing", so the first column is updated to itself.
+		if onConflict.Action == ConflictActionDoNothing || len(updateColumns) == 0 {
+			column := fields[0]
+			if len(onConflict.Columns) > 0 {
+				column = onConflict.Columns[0]
+			}
+			return goqu.DoUpdate("", goqu.Record{column: goqu.I(column)}), nil
+		}
+
+		sets := make(goqu.Record, len(updateColumns))
+		for _, c := range updateColumns {
+			sets[c] = goqu.L("VALUES(?)", goqu.I(c))
+		}
+		return goqu.DoUpdate("", sets), nil
+	}
+
+	if onConflict.Action == ConflictActionDoNothing || len(updateColumns) == 0 {
+		return goqu.DoNothing(), nil
+	}
+	if len(onConflict.Columns) == 0 {
+		return nil, errConflictColumns
+	}
+
+	// the target of goqu is written as it is, so its columns are quoted like the other identifiers of the dialect
+	quote := string(conflictDialects[dialect].QuoteRune)
+	target := make([]string, len(onConflict.Columns))
+	for i, c := range onConflict.Columns {
+		target[i] = quote + c + quote
+	}
+
+	sets := make(goqu.Record, len(updateColumns))
+	for _, c := range updateColumns {
+		sets[c] = goqu.L("EXCLUDED.?", goqu.I(c))
+	}
+	return goqu.DoUpdate(strings.Join(target, ", "), sets), nil
+}
+
+// buildMergeQuery builds a MERGE query for SQL Server, which does not support conflict clauses.
+func buildMergeQuery(table string, fields []string, withObject bool, onConflict *OnConflict) (string, []interface{}, error) {
+	if len(onConflict.Columns) == 0 {
+		return "", nil, errConflictColumns
+	}
+
+	quotedFields := make([]string, len(fields))
+	values := make([]string, len(fields))
+	sourceFields := make([]string, len(fields))
+	var args []interface{}
+	for i, f := range fields {
+		quotedFields[i] = quoteIdentifier(f)
+		sourceFields[i] = "source." + quoteIdentifier(f)
+		if withObject {
+			values[i] = ":" + f
+		} else {
+			values[i] = placeholder(SQLServer, i+1)
+			args = append(args, setArgument(f))
+		}
+	}
+
+	conditions := make([]string, len(onConflict.Columns))
+	for i, c := range onConflict.Columns {
+		conditions[i] = fmt.Sprintf("target.%s = source.%s", quoteIdentifier(c), quoteIdentifier(c))
+	}
+
+	query := fmt.Sprintf(
+		"MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES (%s)) AS source (%s) ON (%s)",
+		quoteIdentifier(table),
+		strings.Join(values, ", "),
+		strings.Join(quotedFields, ", "),
+		strings.Join(conditions, " AND "),
+	)
+
+	updateColumns := conflictUpdateColumns(fields, onConflict)
+	if onConflict.Action != ConflictActionDoNothing && len(updateColumns) > 0 {
+		sets := make([]string, len(updateColumns))
+		for i, c := range updateColumns {
+			sets[i] = fmt.Sprintf("target.%s = source.%s", quoteIdentifier(c), quoteIdentifier(c))
+		}
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+	}
+
+	query += fmt.Sprintf(
+		" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		strings.Join(quotedFields, ", "),
+		strings.Join(sourceFields, ", "),
+	)
+
+	return query, args, nil
+}
+
+// conflictUpdateColumns returns the columns that must be updated on conflict.
+// As default, all inserted fields except the conflict columns are updated.
+func conflictUpdateColumns(fields []string, onConflict *OnConflict) []string {
+	if len(onConflict.UpdateColumns) > 0 {
+		return onConflict.UpdateColumns
+	}
+
+	conflictColumns := make(map[string]struct{}, len(onConflict.Columns))
+	for _, c := range onConflict.Columns {
+		conflictColumns[c] = struct{}{}
+	}
+
+	var columns []string
+	for _, f := range fields {
+		if _, ok := conflictColumns[f]; !ok {
+			columns = append(columns, f)
+		}
+	}
+
+	return columns
+}
+
+// quoteIdentifier quotes an identifier of a MERGE query in the same way goqu does for SQL Server.
+func quoteIdentifier(identifier string) string {
+	return `"` + identifier + `"`
+}
+
+// BuildDeleteQuery builds a delete query. If softDeleteColumn is set, it builds
+// an update query that sets the column to the current timestamp instead. A query
+// without where conditions deletes every row, so it is only built if allRows is set.
+func BuildDeleteQuery(
+	dialect DialectType,
+	table string,
+	where []WhereCondition,
+	softDeleteColumn string,
+	allRows bool,
+) (string, []interface{}, error) {
+	if len(where) == 0 && !allRows {
+		return "", nil, &FieldError{
+			Field:   "where_conditions",
+			Message: "delete without where conditions deletes every row, set all_rows to generate it",
+		}
+	}
+	if len(where) > 0 && allRows {
+		return "", nil, &FieldError{
+			Field:   "all_rows",
+			Value:   "true",
+			Message: "all_rows cannot be set with where conditions",
+		}
+	}
+
+	d := goqu.Dialect(string(dialect))
+
+	whereExpressions, err := buildWhereExpressions(where, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var query string
+	var args []interface{}
+	if softDeleteColumn != "" {
+		whereExpressions = append(whereExpressions, goqu.I(softDeleteColumn).IsNull())
+		query, args, err = d.Update(table).
+			Prepared(true).
+			Set(goqu.Record{softDeleteColumn: goqu.L("CURRENT_TIMESTAMP")}).
+			Where(whereExpressions...).
+			ToSQL()
+	} else {
+		ds := d.Delete(table).Prepared(true)
+		if len(whereExpressions) > 0 {
+			ds = ds.Where(whereExpressions...)
+		}
+		query, args, err = ds.ToSQL()
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid delete query of table %s: %w", table, err)
+	}
+
+	return query, args, nil
+}
+
+// buildWhereExpressions converts where conditions to goqu expressions in the same order as they are defined,
+// so the arguments of the generated functions can be passed in the same order.
+func buildWhereExpressions(where []WhereCondition, withObject bool) ([]goqu.Expression, error) {
+	expressions := make([]goqu.Expression, 0, len(where))
+	for i, cond := range where {
+		var value interface{} = whereArgument(i)
+		if withObject {
+			value = goqu.L(":" + cond.Column)
+		}
+
+		column := goqu.I(cond.Column)
+		switch cond.Operator {
+		case OperatorTypeEqual:
+			expressions = append(expressions, column.Eq(value))
+		case OperatorTypeNotEqual:
+			expressions = append(expressions, column.Neq(value))
+		case OperatorTypeIn:
+			expressions = append(expressions, column.In(value))
+		case OperatorTypeNotIn:
+			expressions = append(expressions, column.NotIn(value))
+		case OperatorTypeGt:
+			expressions = append(expressions, column.Gt(value))
+		case OperatorTypeGte:
+			expressions = append(expressions, column.Gte(value))
+		case OperatorTypeLt:
+			expressions = append(expressions, column.Lt(value))
+		case OperatorTypeLte:
+			expressions = append(expressions, column.Lte(value))
+		case OperatorTypeIsNull:
+			expressions = append(expressions, column.IsNull())
+		case OperatorTypeIsNotNull:
+			expressions = append(expressions, column.IsNotNull())
+		default:
+			return nil, &FieldError{
+				Field:   "where_conditions",
+				Value:   string(cond.Operator),
+				Message: fmt.Sprintf("invalid operator %q of column %s", cond.Operator, cond.Column),
+			}
+		}
+	}
+
+	return expressions, nil
+}
+
+// argumentPrefix marks the values that are replaced by the arguments of the generated functions.
+// Queries are built in goqu's prepared mode, so each marker becomes a placeholder of the dialect,
+// and the order of the markers in the arguments of the query is the order of the function's arguments.
+const argumentPrefix = "\x00crafting-table:"
+
+// whereArgument is the marker of the value of the where condition with the index
+func whereArgument(index int) string {
+	return argumentPrefix + "where:" + strconv.Itoa(index)
+}
+
+// setArgument is the marker of the value that is set to the column in insert and update queries
+func setArgument(column string) string {
+	return argumentPrefix + "set:" + column
+}
+
+// placeholder returns the nth placeholder of the dialect
+func placeholder(dialect DialectType, n int) string {
+	switch dialect {
+	case Postgres:
+		return "$" + strconv.Itoa(n)
+	case SQLServer:
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// BuildArguments converts the arguments of a prepared query to the list of arguments that the generated
+// function passes to the query. variable returns the name of the variable for the key of a marker
+// (e.g. "where:0" or "set:name"), and other arguments (e.g. limit) are passed as constants.
+func BuildArguments(args []interface{}, variable func(key string) string) string {
+	list := make([]string, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case nil:
+			list[i] = "nil"
+		case string:
+			if strings.HasPrefix(v, argumentPrefix) {
+				list[i] = variable(strings.TrimPrefix(v, argumentPrefix))
+			} else {
+				list[i] = strconv.Quote(v)
+			}
+		default:
+			list[i] = fmt.Sprint(v)
+		}
+	}
 
-	return query
+	return strings.Join(list, ", ")
 }
@@ -0,0 +1,201 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// regionBegin and regionEnd mark a region of hand-written code in a generated file, which is carried over when the
+// file is generated again:
+//
+//	// ct:custom-begin functions
+//	func (d *databaseUser) Count(ctx context.Context) (int, error) { ... }
+//	// ct:custom-end
+//
+// The region replaces the region of the same name in the generated file, and the regions that the generated file
+// does not have are appended to its end.
+const (
+	regionBegin = "// ct:custom-begin"
+	regionEnd   = "// ct:custom-end"
+)
+
+// region is a region of hand-written code. body is the lines between its markers.
+type region struct {
+	name string
+	body string
+	line int
+}
+
+// preserveRegions carries the regions of the file on disk over to its generated content. The imports of the file
+// on disk are kept as well, since the regions may use them, and the unused ones are removed by FormatSource.
+func preserveRegions(path, generated string) (string, []Section, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return generated, nil, nil
+		}
+		return "", nil, err
+	}
+
+	return mergeRegions(path, generated, string(existing))
+}
+
+// mergeRegions returns the generated content with the regions of the existing content, and the sections of the
+// regions, which syntax errors are reported with.
+func mergeRegions(path, generated, existing string) (string, []Section, error) {
+	if _, err := parseRegions("generated "+path, generated); err != nil {
+		return "", nil, err
+	}
+	existingRegions, err := parseRegions(path, existing)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existingRegions) == 0 {
+		return generated, nil, nil
+	}
+
+	bodies := make(map[string]string)
+	for _, r := range existingRegions {
+		bodies[r.name] = r.body
+	}
+
+	var builder strings.Builder
+	var sections []Section
+	used := make(map[string]bool)
+	inRegion := false
+	for _, line := range splitLines(generated) {
+		trimmed := strings.TrimSpace(line)
+		if inRegion {
+			// the generated body of the region is replaced by the existing one
+			if trimmed == regionEnd {
+				builder.WriteString(line)
+				inRegion = false
+			}
+			continue
+		}
+
+		builder.WriteString(line)
+		if name, ok := regionName(trimmed); ok {
+			if body, ok := bodies[name]; ok {
+				builder.WriteString(body)
+				sections = append(sections, Section{Name: "custom region " + name, Source: body})
+				used[name] = true
+				inRegion = true
+			}
+		}
+	}
+
+	for _, r := range existingRegions {
+		if used[r.name] {
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("\n%s %s\n%s%s\n", regionBegin, r.name, r.body, regionEnd))
+		sections = append(sections, Section{Name: "custom region " + r.name, Source: r.body})
+	}
+
+	return withImports(builder.String(), existing), sections, nil
+}
+
+// parseRegions returns the regions of a file in their order.
+func parseRegions(path, content string) ([]region, error) {
+	var regions []region
+	var current *region
+	lines := make(map[string]int)
+
+	for i, line := range splitLines(content) {
+		trimmed := strings.TrimSpace(line)
+		if name, ok := regionName(trimmed); ok {
+			if current != nil {
+				return nil, fmt.Errorf("%s:%d: custom region %q begins before custom region %q ends",
+					path, i+1, name, current.name)
+			}
+			if name == "" {
+				return nil, fmt.Errorf("%s:%d: custom region has no name", path, i+1)
+			}
+			if line, ok := lines[name]; ok {
+				return nil, fmt.Errorf("%s:%d: custom region %q is already defined at line %d", path, i+1, name, line)
+			}
+
+			lines[name] = i + 1
+			current = &region{name: name, line: i + 1}
+			continue
+		}
+
+		if trimmed == regionEnd {
+			if current == nil {
+				return nil, fmt.Errorf("%s:%d: %s without %s", path, i+1, regionEnd, regionBegin)
+			}
+
+			regions = append(regions, *current)
+			current = nil
+			continue
+		}
+
+		if current != nil {
+			current.body += line
+		}
+	}
+
+	if current != nil {
+		return nil, fmt.Errorf("%s:%d: custom region %q is not ended by %s", path, current.line, current.name, regionEnd)
+	}
+
+	return regions, nil
+}
+
+// regionName returns the name of a region if the line begins one.
+func regionName(line string) (string, bool) {
+	if line == regionBegin {
+		return "", true
+	}
+	if !strings.HasPrefix(line, regionBegin+" ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(line, regionBegin)), true
+}
+
+// withImports adds the imports of the existing content to the source. The source is returned as is if either of
+// them does not parse, and FormatSource reports the syntax error of the source.
+func withImports(source, existing string) string {
+	existingFile, err := parser.ParseFile(token.NewFileSet(), "", existing, parser.ImportsOnly)
+	if err != nil || len(existingFile.Imports) == 0 {
+		return source
+	}
+
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", source, parser.ParseComments)
+	if err != nil {
+		return source
+	}
+
+	for _, spec := range existingFile.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		astutil.AddNamedImport(fileSet, file, name, path)
+	}
+
+	var buffer bytes.Buffer
+	if err := format.Node(&buffer, fileSet, file); err != nil {
+		return source
+	}
+
+	return buffer.String()
+}
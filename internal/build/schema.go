@@ -0,0 +1,103 @@
+package build
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// JSONSchema returns the JSON Schema of manifest files, which editors use to complete and check them. A manifest
+// is a repository or a list of repositories under the `repositories` key.
+func JSONSchema() ([]byte, error) {
+	definitions := make(map[string]interface{})
+	repo := schemaOf(reflect.TypeOf(Repo{}), definitions)
+
+	schema := map[string]interface{}{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title":   "crafting-table manifest",
+		"anyOf": []interface{}{
+			repo,
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repositories": map[string]interface{}{"type": "array", "items": repo},
+				},
+				"required":             []string{"repositories"},
+				"additionalProperties": false,
+			},
+		},
+		"definitions": definitions,
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaOf returns the schema of a type of the manifest. Structs are added to the definitions and referred by their
+// names.
+func schemaOf(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := definitions[t.Name()]; !ok {
+			// the definition is added before its fields, so the types that refer to themselves end
+			definitions[t.Name()] = nil
+
+			properties := make(map[string]interface{})
+			for _, field := range orderedYAMLFields(t) {
+				properties[field.Name] = schemaOf(field.Type, definitions)
+			}
+			if t == reflect.TypeOf(AggregateField{}) {
+				properties["function"] = map[string]interface{}{"type": "string", "enum": aggregateFunctionEnum()}
+			}
+			// the entries of the sections of the registered generators are only known by their generators
+			if t == reflect.TypeOf(Repo{}) {
+				for _, section := range generator.Sections() {
+					properties[section] = map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}}
+				}
+			}
+
+			definition := map[string]interface{}{
+				"type":                 "object",
+				"properties":           properties,
+				"additionalProperties": false,
+			}
+			if required, ok := requiredFields[t]; ok {
+				definition["required"] = required
+			}
+			definitions[t.Name()] = definition
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaOf(t.Elem(), definitions)}
+
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	}
+
+	schema := map[string]interface{}{"type": "string"}
+	if values, ok := enumValues[t]; ok {
+		schema["enum"] = values
+	}
+	return schema
+}
+
+// aggregateFunctionEnum returns the aggregate functions in upper and lower case, since they are case-insensitive.
+func aggregateFunctionEnum() []string {
+	var values []string
+	for _, function := range aggregateFunctions {
+		values = append(values, function, strings.ToLower(function))
+	}
+	return values
+}
@@ -2,16 +2,18 @@ package build
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
-	"text/template"
 
+	"github.com/gertd/go-pluralize"
 	"github.com/iancoleman/strcase"
 
 	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
 )
 
 func BuildGetFunction(
+	tmpl *templates.Set,
 	structure *structure.Structure,
 	dialect DialectType,
 	table string,
@@ -23,8 +25,13 @@ func BuildGetFunction(
 	limit *uint,
 	groupBy []string,
 	join []JoinField,
+	softDeleteColumn string,
 	customFunctionName string,
-) (function string, signature string) {
+) (function string, signature string, test string, statement Statement, err error) {
+	if errs := whereErrors(structure, where, true); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
+	}
+
 	// converting a []string to a []interface{}
 	fieldsInterface := make([]interface{}, len(fields))
 	groupByInterface := make([]interface{}, len(groupBy))
@@ -36,7 +43,7 @@ func BuildGetFunction(
 	}
 
 	// create query
-	q := BuildSelectQuery(
+	q, args, err := BuildSelectQuery(
 		dialect,
 		table,
 		fieldsInterface,
@@ -47,35 +54,33 @@ func BuildGetFunction(
 		limit,
 		groupByInterface,
 		join,
+		softDeleteColumn,
 	)
-
-	// fields: prepare functionName
-	whereColumns := make([]string, len(where))
-	for i, v := range where {
-		whereColumns[i] = strcase.ToCamel(v.Column)
+	if err != nil {
+		return "", "", "", Statement{}, err
 	}
 
-	var functionName string
-	if customFunctionName == "" {
-		functionName = "Get"
-		if len(whereColumns) > 0 {
-			functionName += "By" + strings.Join(whereColumns, "And") // GetByColumn1AndColumn2AndColumn3
-		}
-	} else {
-		functionName = customFunctionName
-	}
+	// fields: prepare functionName
+	functionName := functionNameOf(customFunctionName, "Get", where)
 
 	// fields: prepare inputs
-	inputWithTypeList := make([]string, len(where))
-	inputList := make([]string, len(where))
-
+	variables := whereVariablesOf(where, make(map[string]struct{}))
+	var inputWithTypeList []string
+	var inputList []string
+	var testVariables []TestVariable
 	for i, v := range where {
-		inputList[i] = strcase.ToLowerCamel(v.Column)
-		inputWithTypeList[i] = strcase.ToLowerCamel(v.Column) + " " +
-			structure.FieldMapNameToType[structure.FieldMapDBFlagToName[v.Column]]
+		name, ok := variables[i]
+		if !ok {
+			continue
+		}
+
+		fieldType := structure.FieldMapNameToType[structure.FieldMapDBFlagToName[v.Column]]
+		inputList = append(inputList, name)
+		inputWithTypeList = append(inputWithTypeList, name+" "+fieldType)
+		testVariables = append(testVariables, TestVariable{Name: name, Type: fieldType})
 	}
 	inputsWithType := strings.Join(inputWithTypeList, ", ")
-	inputs := strings.Join(inputList, ", ")
+	inputs := BuildArguments(args, argumentVariable(nil, variables))
 
 	// fields: prepare DesStructTemplate
 	// TODO: add fields to DesStructTemplate
@@ -91,7 +96,7 @@ func BuildGetFunction(
 	// fields: prepare model
 	model := structure.PackageName + "." + structure.Name
 	if desStructTemplate != "" {
-		model += "structDes\n"
+		model = "structDes\n"
 	}
 
 	// fields: prepare outputs
@@ -110,26 +115,22 @@ func BuildGetFunction(
 	var realOutputList []string
 	if len(aggregate) > 0 {
 		for _, v := range aggregate {
-			realOutputList = append(realOutputList, "&dst"+strcase.ToCamel(v.As))
+			realOutputList = append(realOutputList, "&dst."+strcase.ToCamel(v.As))
 		}
 	} else {
 		realOutputList = append(realOutputList, "&dst")
 	}
 
 	// create signature
-	signatureData := struct {
-		FuncName string
-		Inputs   string
-		Outputs  string
-	}{
+	signatureData := SignatureData{
 		FuncName: functionName,
 		Inputs:   inputsWithType,
 		Outputs:  outputs,
 	}
 
 	var signatureBuilder strings.Builder
-	if err := signatureTemplate.Execute(&signatureBuilder, signatureData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	signature = signatureBuilder.String()
 
@@ -144,14 +145,8 @@ func BuildGetFunction(
 		specialQuery = true
 	}
 
-	getQueryData := struct {
-		Query                  string
-		SpecialQuery           bool
-		Dest                   string
-		OutputsWithNotFoundErr string
-		OutputsWithErr         string
-		Inputs                 string
-	}{
+	getQueryData := QueryData{
+		FuncName:     functionName,
 		Query:        q,
 		SpecialQuery: specialQuery,
 		Dest:         "dst",
@@ -161,21 +156,16 @@ func BuildGetFunction(
 		Inputs:         inputs,
 	}
 	var getContextBuilder strings.Builder
-	if err := getContextTemplate.Execute(&getContextBuilder, getQueryData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&getContextBuilder, "getContext", getQueryData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	getContextQuery := getContextBuilder.String()
 
 	// create function
-	functionData := struct {
-		ModelName         string
-		Signature         string
-		DesStructTemplate string
-		DstModel          string
-		ExecQueryTemplate string
-		Outputs           string
-	}{
+	functionData := FunctionData{
 		ModelName:         structure.Name,
+		FuncName:          functionName,
+		Query:             q,
 		Signature:         signature,
 		DesStructTemplate: desStructTemplate,
 		DstModel:          model,
@@ -184,15 +174,34 @@ func BuildGetFunction(
 	}
 
 	var functionBuilder strings.Builder
-	if err := functionTemplate.Execute(&functionBuilder, functionData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&functionBuilder, "function", functionData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	function = functionBuilder.String()
 
-	return function, signature
+	// create test
+	aggregateColumns := make([]string, len(aggregate))
+	for i, v := range aggregate {
+		aggregateColumns[i] = v.As
+	}
+	test, err = buildFunctionTest(tmpl, structure, functionName, functionTest{
+		Kind:       testKindGet,
+		Variables:  testVariables,
+		Call:       strings.Join(inputList, ", "),
+		Query:      q,
+		Args:       inputs,
+		Columns:    fields,
+		Aggregates: aggregateColumns,
+	})
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	return function, signature, test, Statement{FunctionName: functionName, Query: q}, nil
 }
 
 func BuildSelectFunction(
+	tmpl *templates.Set,
 	structure *structure.Structure,
 	dialect DialectType,
 	table string,
@@ -204,8 +213,13 @@ func BuildSelectFunction(
 	limit *uint,
 	groupBy []string,
 	join []JoinField,
+	softDeleteColumn string,
 	customFunctionName string,
-) (function string, signature string) {
+) (function string, signature string, test string, statement Statement, err error) {
+	if errs := whereErrors(structure, where, true); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
+	}
+
 	// converting a []string to a []interface{}
 	fieldsInterface := make([]interface{}, len(fields))
 	groupByInterface := make([]interface{}, len(groupBy))
@@ -217,7 +231,7 @@ func BuildSelectFunction(
 	}
 
 	// create query
-	q := BuildSelectQuery(
+	q, args, err := BuildSelectQuery(
 		dialect,
 		table,
 		fieldsInterface,
@@ -228,34 +242,33 @@ func BuildSelectFunction(
 		limit,
 		groupByInterface,
 		join,
+		softDeleteColumn,
 	)
-
-	// fields: prepare functionName
-	whereColumns := make([]string, len(where))
-	for i, v := range where {
-		whereColumns[i] = strcase.ToCamel(v.Column)
+	if err != nil {
+		return "", "", "", Statement{}, err
 	}
 
-	var functionName string
-	if customFunctionName == "" {
-		functionName = "Select"
-		if len(whereColumns) > 0 {
-			functionName += "By" + strings.Join(whereColumns, "And") // GetByColumn1AndColumn2AndColumn3
-		}
-	} else {
-		functionName = customFunctionName
-	}
+	// fields: prepare functionName
+	functionName := functionNameOf(customFunctionName, "Select", where)
 
 	// fields: prepare inputs
-	inputWithTypeList := make([]string, len(where))
-	inputList := make([]string, len(where))
+	variables := whereVariablesOf(where, make(map[string]struct{}))
+	var inputWithTypeList []string
+	var inputList []string
+	var testVariables []TestVariable
 	for i, v := range where {
-		inputList[i] = strcase.ToLowerCamel(v.Column)
-		inputWithTypeList[i] = strcase.ToLowerCamel(v.Column) + " " +
-			structure.FieldMapNameToType[structure.FieldMapDBFlagToName[v.Column]]
+		name, ok := variables[i]
+		if !ok {
+			continue
+		}
+
+		fieldType := structure.FieldMapNameToType[structure.FieldMapDBFlagToName[v.Column]]
+		inputList = append(inputList, name)
+		inputWithTypeList = append(inputWithTypeList, name+" "+fieldType)
+		testVariables = append(testVariables, TestVariable{Name: name, Type: fieldType})
 	}
 	inputsWithType := strings.Join(inputWithTypeList, ", ")
-	inputs := strings.Join(inputList, ", ")
+	inputs := BuildArguments(args, argumentVariable(nil, variables))
 
 	// fields: prepare DesStructTemplate
 	desStructTemplate := ""
@@ -268,46 +281,50 @@ func BuildSelectFunction(
 	}
 
 	// fields: prepare model
-	model := structure.PackageName + "." + structure.Name
+	model := "[]" + structure.PackageName + "." + structure.Name
 	if desStructTemplate != "" {
-		model = "structDes\n"
+		model = "[]structDes\n"
 	}
 
 	// fields: prepare outputs
 	var outputList []string
 	if len(aggregate) > 0 {
 		for i := 1; i <= len(aggregate); i++ {
-			outputList = append(outputList, "*int")
+			outputList = append(outputList, "[]int")
 		}
 	} else {
-		outputList = append(outputList, "*"+structure.PackageName+"."+structure.Name)
+		outputList = append(outputList, "[]"+structure.PackageName+"."+structure.Name)
 	}
 	outputList = append(outputList, "error")
 	outputs := strings.Join(outputList, ", ")
 
-	// fields: prepare real outputs without error
+	// fields: prepare real outputs without error, which are a slice of every aggregate field of the rows
 	var realOutputList []string
+	aggregateRows := ""
 	if len(aggregate) > 0 {
+		aggregateRows = "\n"
 		for _, v := range aggregate {
-			realOutputList = append(realOutputList, "&dst."+strcase.ToCamel(v.As))
+			realOutputList = append(realOutputList, "dst"+strcase.ToCamel(v.As))
+			aggregateRows += "dst" + strcase.ToCamel(v.As) + " := make([]int, len(dst))\n"
 		}
+		aggregateRows += "for i, row := range dst {\n"
+		for _, v := range aggregate {
+			aggregateRows += "dst" + strcase.ToCamel(v.As) + "[i] = row." + strcase.ToCamel(v.As) + "\n"
+		}
+		aggregateRows += "}\n"
 	} else {
-		realOutputList = append(realOutputList, "&dst")
+		realOutputList = append(realOutputList, "dst")
 	}
 
 	// create signature
-	signatureData := struct {
-		FuncName string
-		Inputs   string
-		Outputs  string
-	}{
+	signatureData := SignatureData{
 		FuncName: functionName,
 		Inputs:   inputsWithType,
 		Outputs:  outputs,
 	}
 	var signatureBuilder strings.Builder
-	if err := signatureTemplate.Execute(&signatureBuilder, signatureData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	signature = signatureBuilder.String()
 
@@ -323,13 +340,8 @@ func BuildSelectFunction(
 		specialQuery = true
 	}
 
-	execQueryData := struct {
-		Query          string
-		SpecialQuery   bool
-		Dest           string
-		OutputsWithErr string
-		Inputs         string
-	}{
+	execQueryData := QueryData{
+		FuncName:       functionName,
 		Query:          q,
 		SpecialQuery:   specialQuery,
 		Dest:           "dst",
@@ -337,21 +349,16 @@ func BuildSelectFunction(
 		Inputs:         inputs,
 	}
 	var selectContextBuilder strings.Builder
-	if err := selectContextTemplate.Execute(&selectContextBuilder, execQueryData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&selectContextBuilder, "selectContext", execQueryData); err != nil {
+		return "", "", "", Statement{}, err
 	}
-	selectContextQuery := selectContextBuilder.String()
+	selectContextQuery := selectContextBuilder.String() + aggregateRows
 
 	// create function
-	functionData := struct {
-		ModelName         string
-		Signature         string
-		DesStructTemplate string
-		DstModel          string
-		ExecQueryTemplate string
-		Outputs           string
-	}{
+	functionData := FunctionData{
 		ModelName:         structure.Name,
+		FuncName:          functionName,
+		Query:             q,
 		Signature:         signature,
 		DesStructTemplate: desStructTemplate,
 		DstModel:          model,
@@ -360,40 +367,52 @@ func BuildSelectFunction(
 	}
 
 	var functionBuilder strings.Builder
-	if err := functionTemplate.Execute(&functionBuilder, functionData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&functionBuilder, "function", functionData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	function = functionBuilder.String()
 
-	return function, signature
-}
+	// create test
+	aggregateColumns := make([]string, len(aggregate))
+	for i, v := range aggregate {
+		aggregateColumns[i] = v.As
+	}
+	test, err = buildFunctionTest(tmpl, structure, functionName, functionTest{
+		Kind:       testKindSelect,
+		Variables:  testVariables,
+		Call:       strings.Join(inputList, ", "),
+		Query:      q,
+		Args:       inputs,
+		Columns:    fields,
+		Aggregates: aggregateColumns,
+	})
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
 
-// TODO: add more functions like: update, insert.
+	return function, signature, test, Statement{FunctionName: functionName, Query: q}, nil
+}
 
 func BuildInsertFunction(
+	tmpl *templates.Set,
 	structure *structure.Structure,
 	dialect DialectType,
 	table string,
 	fields []string,
 	withObject bool,
+	onConflict *OnConflict,
 	customFunctionName string,
-) (function string, signature string) {
-	var functionName string
-	if customFunctionName == "" {
-		functionName = "Create"
-	} else {
-		functionName = customFunctionName
-	}
+) (function string, signature string, test string, statement Statement, err error) {
+	functionName := functionNameOf(customFunctionName, "Create", nil)
 
-	for _, f := range fields {
-		_, ok := structure.FieldMapDBFlagToName[f]
-		if !ok {
-			log.Fatalf("field %s not found in structure", f)
-		}
+	if errs := fieldErrors(structure, fields); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
 	}
 
 	var inputs string
 	var execVars string
+	var testVariables []TestVariable
+	setVariables := make(map[string]string)
 	if withObject {
 		inputs = fmt.Sprintf(
 			"%s *%s.%s",
@@ -402,41 +421,43 @@ func BuildInsertFunction(
 			structure.Name)
 	} else {
 		if len(fields) == 0 {
-			panic("fields is empty")
+			return "", "", "", Statement{}, &FieldError{Field: "fields", Message: "fields is empty"}
 		}
 
 		for _, f := range fields {
 			name := structure.FieldMapDBFlagToName[f]
 			fieldType := structure.FieldMapNameToType[name]
 			inputs += fmt.Sprintf("%s %s, ", strcase.ToLowerCamel(name), fieldType)
-			execVars += fmt.Sprintf("%s, ", strcase.ToLowerCamel(name))
+			setVariables[f] = strcase.ToLowerCamel(name)
+			testVariables = append(testVariables, TestVariable{Name: strcase.ToLowerCamel(name), Type: fieldType})
 		}
 	}
 
 	// make functions signature
-	signatureData := struct {
-		FuncName string
-		Inputs   string
-		Outputs  string
-	}{
+	signatureData := SignatureData{
 		FuncName: functionName,
 		Inputs:   inputs,
 		Outputs:  "error",
 	}
 	var signatureBuilder strings.Builder
-	if err := signatureTemplate.Execute(&signatureBuilder, signatureData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 
 	signature = signatureBuilder.String()
 
 	// make functions body
-	insertQuery := BuildInsertQuery(
+	insertQuery, args, err := BuildInsertQuery(
 		dialect,
 		table,
 		fields,
 		withObject,
+		onConflict,
 	)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+	execVars = BuildArguments(args, argumentVariable(setVariables, nil))
 
 	specialQuery := false
 	if dialect == MySQL || dialect == SQLite3 {
@@ -445,102 +466,607 @@ func BuildInsertFunction(
 
 	var execQueryBuilder strings.Builder
 	if withObject {
-		execQueryData := struct {
-			SpecialQuery bool
-			Query        string
-			Dest         string
-		}{
+		execQueryData := QueryData{
+			FuncName:     functionName,
 			SpecialQuery: specialQuery,
 			Query:        insertQuery,
 			Dest:         strcase.ToLowerCamel(structure.Name),
 		}
-		if err := namedExecContextTemplate.Execute(&execQueryBuilder, execQueryData); err != nil {
-			panic(err)
+		if err := tmpl.Execute(&execQueryBuilder, "namedExecContext", execQueryData); err != nil {
+			return "", "", "", Statement{}, err
 		}
 	} else {
-		execQueryData := struct {
-			SpecialQuery bool
-			Query        string
-			ExecVars     string
-		}{
+		execQueryData := QueryData{
+			FuncName:     functionName,
 			SpecialQuery: specialQuery,
 			Query:        insertQuery,
 			ExecVars:     execVars,
 		}
-		if err := execContextTemplate.Execute(&execQueryBuilder, execQueryData); err != nil {
-			panic(err)
+		if err := tmpl.Execute(&execQueryBuilder, "execContext", execQueryData); err != nil {
+			return "", "", "", Statement{}, err
 		}
 	}
 
 	insertContextQuery := execQueryBuilder.String()
 
-	functionData := struct {
-		ModelName         string
-		Signature         string
-		ExecQueryTemplate string
-		Outputs           string
-	}{
+	functionData := FunctionData{
 		ModelName:         structure.Name,
+		FuncName:          functionName,
+		Query:             insertQuery,
 		Signature:         signature,
 		ExecQueryTemplate: insertContextQuery,
 		Outputs:           "nil",
 	}
 
 	var functionBuilder strings.Builder
-	if err := insertFunctionTemplate.Execute(&functionBuilder, functionData); err != nil {
-		panic(err)
+	if err := tmpl.Execute(&functionBuilder, "insertFunction", functionData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	function = functionBuilder.String()
+
+	// create test
+	callVars := make([]string, len(testVariables))
+	for i, v := range testVariables {
+		callVars[i] = v.Name
+	}
+	insertTest := functionTest{
+		Kind:      testKindExec,
+		Variables: testVariables,
+		Call:      strings.Join(callVars, ", "),
+		Query:     insertQuery,
+		Args:      execVars,
+	}
+	if withObject {
+		object := objectVariable(structure)
+		query, columns := compileNamedQuery(dialect, insertQuery)
+		insertTest.Variables = []TestVariable{object}
+		insertTest.Call = "&" + object.Name
+		insertTest.Query = query
+		insertTest.Args = objectArgs(structure, object.Name, columns)
+	}
+	test, err = buildFunctionTest(tmpl, structure, functionName, insertTest)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	return function, signature, test, Statement{FunctionName: functionName, Query: insertQuery}, nil
+}
+
+func BuildBulkInsertFunction(
+	tmpl *templates.Set,
+	structure *structure.Structure,
+	dialect DialectType,
+	table string,
+	fields []string,
+	onConflict *OnConflict,
+	batchSize int,
+	customFunctionName string,
+) (function string, signature string, test string, statement Statement, err error) {
+	functionName := functionNameOf(customFunctionName, "CreateMany", nil)
+
+	if len(fields) == 0 {
+		return "", "", "", Statement{}, &FieldError{Field: "fields", Message: "fields is empty"}
+	}
+
+	if errs := fieldErrors(structure, fields); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
+	}
+
+	// fields: prepare inputs
+	item := strcase.ToLowerCamel(structure.Name)
+	input := pluralize.NewClient().Plural(item)
+	if input == item {
+		input = item + "List"
+	}
+
+	args := make([]string, len(fields))
+	for i, f := range fields {
+		args[i] = item + "." + structure.FieldMapDBFlagToName[f]
+	}
+
+	// make functions signature
+	signatureData := SignatureData{
+		FuncName: functionName,
+		Inputs:   fmt.Sprintf("%s []*%s.%s", input, structure.PackageName, structure.Name),
+		Outputs:  "int64, error",
+	}
+	var signatureBuilder strings.Builder
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	signature = signatureBuilder.String()
+
+	// make functions body
+	prefix, suffix, err := BuildBulkInsertQuery(dialect, table, fields, onConflict)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+	batch := BulkInsertBatchSize(dialect, len(fields), batchSize)
+
+	functionData := BulkInsertFunctionData{
+		ModelName:   structure.Name,
+		FuncName:    functionName,
+		Signature:   signature,
+		BatchSize:   batch,
+		Input:       input,
+		Item:        item,
+		FieldsCount: len(fields),
+		Prefix:      strconv.Quote(prefix),
+		Row:         bulkInsertRowExpression(dialect, len(fields)),
+		Suffix:      strconv.Quote(suffix),
+		Args:        strings.Join(args, ", "),
+	}
+
+	var functionBuilder strings.Builder
+	if err := tmpl.Execute(&functionBuilder, "bulkInsertFunction", functionData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	function = functionBuilder.String()
+
+	// create test with rows that fit in one batch
+	count := 2
+	if batch < count {
+		count = batch
+	}
+	testRows := make([]string, count)
+	var testArgs []string
+	for i := 0; i < count; i++ {
+		testRows[i] = BulkInsertRow(dialect, len(fields), i*len(fields))
+		for _, f := range fields {
+			testArgs = append(testArgs, fmt.Sprintf("%s[%d].%s", input, i, structure.FieldMapDBFlagToName[f]))
+		}
+	}
+	test, err = buildFunctionTest(tmpl, structure, functionName, functionTest{
+		Kind:         testKindExecWithResult,
+		Setup:        bulkTestSetup(structure, input, count),
+		Call:         input,
+		Query:        prefix + strings.Join(testRows, ", ") + suffix,
+		Args:         strings.Join(testArgs, ", "),
+		RowsAffected: count,
+		SkipNotFound: true,
+	})
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	return function, signature, test, Statement{FunctionName: functionName, Query: prefix + BulkInsertRow(dialect, len(fields), 0) + suffix}, nil
+}
+
+// bulkInsertRowExpression returns the expression that bulk insert functions use for the values of each row.
+// Placeholders of dialects that number them continue after the arguments of the previous rows.
+func bulkInsertRowExpression(dialect DialectType, fieldsCount int) string {
+	if placeholder(dialect, 1) == placeholder(dialect, 2) {
+		return strconv.Quote(BulkInsertRow(dialect, fieldsCount, 0))
+	}
+
+	formats := make([]string, fieldsCount)
+	numbers := make([]string, fieldsCount)
+	for i := 0; i < fieldsCount; i++ {
+		formats[i] = strings.TrimSuffix(placeholder(dialect, 1), "1") + "%d"
+		numbers[i] = fmt.Sprintf("len(args)+%d", i+1)
+	}
+
+	return fmt.Sprintf("fmt.Sprintf(%s, %s)",
+		strconv.Quote("("+strings.Join(formats, ", ")+")"),
+		strings.Join(numbers, ", "))
+}
+
+func BuildUpdateFunction(
+	tmpl *templates.Set,
+	structure *structure.Structure,
+	dialect DialectType,
+	table string,
+	fields []string,
+	where []WhereCondition,
+	withObject bool,
+	customFunctionName string,
+) (function string, signature string, test string, statement Statement, err error) {
+	if len(fields) == 0 {
+		return "", "", "", Statement{}, &FieldError{Field: "fields", Message: "update fields is empty"}
+	}
+
+	if errs := append(fieldErrors(structure, fields), whereErrors(structure, where, false)...); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
+	}
+
+	// fields: prepare functionName
+	functionName := functionNameOf(customFunctionName, "Update", where)
+
+	// fields: prepare inputs
+	var inputs string
+	var testVariables []TestVariable
+	setVariables := make(map[string]string)
+	whereVariables := make(map[int]string)
+	if withObject {
+		inputs = fmt.Sprintf(
+			"%s *%s.%s",
+			strcase.ToLowerCamel(structure.Name),
+			structure.PackageName,
+			structure.Name)
+	} else {
+		taken := make(map[string]struct{})
+		var inputList []string
+		for _, f := range fields {
+			name := structure.FieldMapDBFlagToName[f]
+			setVariables[f] = strcase.ToLowerCamel(name)
+			taken[setVariables[f]] = struct{}{}
+			inputList = append(inputList, fmt.Sprintf("%s %s", setVariables[f], structure.FieldMapNameToType[name]))
+			testVariables = append(testVariables, TestVariable{
+				Name: setVariables[f],
+				Type: structure.FieldMapNameToType[name],
+			})
+		}
+
+		whereVariables = whereVariablesOf(where, taken)
+		for i, w := range where {
+			variableName, ok := whereVariables[i]
+			if !ok {
+				continue
+			}
+
+			fieldType := structure.FieldMapNameToType[structure.FieldMapDBFlagToName[w.Column]]
+			inputList = append(inputList, fmt.Sprintf("%s %s", variableName, fieldType))
+			testVariables = append(testVariables, TestVariable{
+				Name: variableName,
+				Type: fieldType,
+			})
+		}
+		inputs = strings.Join(inputList, ", ")
+	}
+
+	// make functions signature
+	signatureData := SignatureData{
+		FuncName: functionName,
+		Inputs:   inputs,
+		Outputs:  "int64, error",
+	}
+	var signatureBuilder strings.Builder
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	signature = signatureBuilder.String()
+
+	// make functions body
+	fieldsInterface := make([]interface{}, len(fields))
+	for i, v := range fields {
+		fieldsInterface[i] = v
+	}
+
+	updateQuery, args, err := BuildUpdateQuery(
+		dialect,
+		table,
+		fieldsInterface,
+		where,
+		withObject,
+	)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	specialQuery := false
+	if dialect == MySQL || dialect == SQLite3 {
+		specialQuery = true
+	}
+
+	updateTest := functionTest{
+		Kind:         testKindExecWithResult,
+		Variables:    testVariables,
+		Query:        updateQuery,
+		RowsAffected: 1,
+	}
+
+	var execQueryBuilder strings.Builder
+	if withObject {
+		execQueryData := QueryData{
+			FuncName:     functionName,
+			SpecialQuery: specialQuery,
+			Query:        updateQuery,
+			Dest:         strcase.ToLowerCamel(structure.Name),
+		}
+		if err := tmpl.Execute(&execQueryBuilder, "namedExecContextWithResult", execQueryData); err != nil {
+			return "", "", "", Statement{}, err
+		}
+	} else {
+		execVars := BuildArguments(args, argumentVariable(setVariables, whereVariables))
+
+		updateTest.Args = execVars
+		for _, v := range testVariables {
+			updateTest.Call += v.Name + ", "
+		}
+		updateTest.Call = strings.TrimSuffix(updateTest.Call, ", ")
+
+		execQueryData := QueryData{
+			FuncName:     functionName,
+			SpecialQuery: specialQuery,
+			Query:        updateQuery,
+			ExecVars:     execVars,
+		}
+		if err := tmpl.Execute(&execQueryBuilder, "execContextWithResult", execQueryData); err != nil {
+			return "", "", "", Statement{}, err
+		}
+	}
+
+	updateContextQuery := execQueryBuilder.String()
+
+	functionData := FunctionData{
+		ModelName:         structure.Name,
+		FuncName:          functionName,
+		Query:             updateQuery,
+		Signature:         signature,
+		ExecQueryTemplate: updateContextQuery,
+	}
+
+	var functionBuilder strings.Builder
+	if err := tmpl.Execute(&functionBuilder, "rowsAffectedFunction", functionData); err != nil {
+		return "", "", "", Statement{}, err
 	}
 	function = functionBuilder.String()
 
-	return function, signature
+	// create test
+	if withObject {
+		object := objectVariable(structure)
+		query, columns := compileNamedQuery(dialect, updateQuery)
+		updateTest.Variables = []TestVariable{object}
+		updateTest.Call = "&" + object.Name
+		updateTest.Query = query
+		updateTest.Args = objectArgs(structure, object.Name, columns)
+	}
+	test, err = buildFunctionTest(tmpl, structure, functionName, updateTest)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	return function, signature, test, Statement{FunctionName: functionName, Query: updateQuery}, nil
+}
+
+func BuildDeleteFunction(
+	tmpl *templates.Set,
+	structure *structure.Structure,
+	dialect DialectType,
+	table string,
+	where []WhereCondition,
+	softDeleteColumn string,
+	allRows bool,
+	customFunctionName string,
+) (function string, signature string, test string, statement Statement, err error) {
+	if errs := whereErrors(structure, where, false); len(errs) > 0 {
+		return "", "", "", Statement{}, errs
+	}
+
+	// fields: prepare functionName
+	functionName := functionNameOf(customFunctionName, "Delete", where)
+
+	// fields: prepare inputs
+	variables := whereVariablesOf(where, make(map[string]struct{}))
+	var inputList []string
+	var callList []string
+	var testVariables []TestVariable
+	for i, w := range where {
+		name, ok := variables[i]
+		if !ok {
+			continue
+		}
+
+		fieldType := structure.FieldMapNameToType[structure.FieldMapDBFlagToName[w.Column]]
+		inputList = append(inputList, fmt.Sprintf("%s %s", name, fieldType))
+		callList = append(callList, name)
+		testVariables = append(testVariables, TestVariable{
+			Name: name,
+			Type: fieldType,
+		})
+	}
+
+	// make functions signature
+	signatureData := SignatureData{
+		FuncName: functionName,
+		Inputs:   strings.Join(inputList, ", "),
+		Outputs:  "int64, error",
+	}
+	var signatureBuilder strings.Builder
+	if err := tmpl.Execute(&signatureBuilder, "signature", signatureData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	signature = signatureBuilder.String()
+
+	// make functions body
+	deleteQuery, args, err := BuildDeleteQuery(
+		dialect,
+		table,
+		where,
+		softDeleteColumn,
+		allRows,
+	)
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+	execVars := BuildArguments(args, argumentVariable(nil, variables))
+
+	specialQuery := false
+	if dialect == MySQL || dialect == SQLite3 {
+		specialQuery = true
+	}
+
+	execQueryData := QueryData{
+		FuncName:     functionName,
+		SpecialQuery: specialQuery,
+		Query:        deleteQuery,
+		ExecVars:     execVars,
+	}
+	var execQueryBuilder strings.Builder
+	if err := tmpl.Execute(&execQueryBuilder, "execContextWithResult", execQueryData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	functionData := FunctionData{
+		ModelName:         structure.Name,
+		FuncName:          functionName,
+		Query:             deleteQuery,
+		Signature:         signature,
+		ExecQueryTemplate: execQueryBuilder.String(),
+	}
+
+	var functionBuilder strings.Builder
+	if err := tmpl.Execute(&functionBuilder, "rowsAffectedFunction", functionData); err != nil {
+		return "", "", "", Statement{}, err
+	}
+	function = functionBuilder.String()
+
+	// create test
+	test, err = buildFunctionTest(tmpl, structure, functionName, functionTest{
+		Kind:         testKindExecWithResult,
+		Variables:    testVariables,
+		Call:         strings.Join(callList, ", "),
+		Query:        deleteQuery,
+		Args:         execVars,
+		RowsAffected: 1,
+	})
+	if err != nil {
+		return "", "", "", Statement{}, err
+	}
+
+	return function, signature, test, Statement{FunctionName: functionName, Query: deleteQuery}, nil
+}
+
+// functionNameOf returns the custom name of a function, or its default name that is the prefix and the columns of
+// the where conditions, e.g. GetByColumn1AndColumn2.
+func functionNameOf(customFunctionName string, prefix string, where []WhereCondition) string {
+	if customFunctionName != "" {
+		return customFunctionName
+	}
+	if len(where) == 0 {
+		return prefix
+	}
+
+	whereColumns := make([]string, len(where))
+	for i, v := range where {
+		whereColumns[i] = strcase.ToCamel(v.Column)
+	}
+	return prefix + "By" + strings.Join(whereColumns, "And")
+}
+
+// fieldErrors returns the errors of the fields that the struct does not have.
+func fieldErrors(structure *structure.Structure, fields []string) FieldErrors {
+	var errs FieldErrors
+	for _, f := range fields {
+		if _, ok := structure.FieldMapDBFlagToName[f]; !ok {
+			errs = append(errs, &FieldError{
+				Field:   "fields",
+				Value:   f,
+				Message: fmt.Sprintf("field %s not found in structure", f),
+			})
+		}
+	}
+
+	return errs
+}
+
+// whereErrors returns the errors of the invalid operators and the where columns that the struct does not have. If
+// onlyInputs is set, only the columns that take a value are checked, since the other columns of select functions can
+// be of the joined tables.
+func whereErrors(structure *structure.Structure, where []WhereCondition, onlyInputs bool) FieldErrors {
+	var errs FieldErrors
+	for _, w := range where {
+		if _, ok := setOperator[w.Operator]; !ok {
+			errs = append(errs, &FieldError{
+				Field:   "where_conditions",
+				Value:   string(w.Operator),
+				Message: fmt.Sprintf("invalid operator %q of column %s", w.Operator, w.Column),
+			})
+		}
+		if onlyInputs && (w.Operator == OperatorTypeIsNull || w.Operator == OperatorTypeIsNotNull) {
+			continue
+		}
+		if _, ok := structure.FieldMapDBFlagToName[w.Column]; !ok {
+			errs = append(errs, &FieldError{
+				Field:   "where_conditions",
+				Value:   w.Column,
+				Message: fmt.Sprintf("where column %s not found in structure", w.Column),
+			})
+		}
+	}
+
+	return errs
+}
+
+// whereVariablesOf returns the names of the inputs for the where conditions that take a value, by their index.
+// Inputs are named after their columns, and names that are already taken get a prefix or the operator as suffix.
+func whereVariablesOf(where []WhereCondition, taken map[string]struct{}) map[int]string {
+	variables := make(map[int]string)
+	for i, w := range where {
+		if w.Operator == OperatorTypeIsNull || w.Operator == OperatorTypeIsNotNull {
+			continue
+		}
+
+		name := strcase.ToLowerCamel(w.Column)
+		if _, ok := taken[name]; ok {
+			name = "where" + strcase.ToCamel(w.Column)
+		}
+		if _, ok := taken[name]; ok {
+			name += strcase.ToCamel(string(w.Operator))
+		}
+		taken[name] = struct{}{}
+		variables[i] = name
+	}
+
+	return variables
+}
+
+// argumentVariable returns the function that maps the markers of a prepared query to the function's inputs
+func argumentVariable(setVariables map[string]string, whereVariables map[int]string) func(key string) string {
+	return func(key string) string {
+		if strings.HasPrefix(key, "where:") {
+			index, _ := strconv.Atoi(strings.TrimPrefix(key, "where:"))
+			return whereVariables[index]
+		}
+
+		return setVariables[strings.TrimPrefix(key, "set:")]
+	}
 }
 
 func BuildRepository(
+	tmpl *templates.Set,
 	signatureTemplateList []string,
 	functionTemplateList []string,
 	packageName string,
 	tableName string,
 	modelName string,
-) (repository string) {
+	imports map[string]string,
+) (repository string, err error) {
 	// fields: prepare builder
 	var builder strings.Builder
 
 	// create repository
-	repositoryData := struct {
-		PackageName string
-		ModelName   string
-		Signatures  string
-		TableName   string
-		Functions   string
-	}{
-		PackageName: packageName,
-		ModelName:   modelName,
-		Signatures:  strings.Join(signatureTemplateList, "\n"),
-		TableName:   tableName,
-		Functions:   strings.Join(functionTemplateList, "\n"),
-	}
-	if err := repositoryTemplate.Execute(&builder, repositoryData); err != nil {
-		panic(err)
+	standardImports, otherImports := structure.ImportSpecs(imports)
+
+	repositoryData := RepositoryData{
+		PackageName:     packageName,
+		ModelName:       modelName,
+		Signatures:      strings.Join(signatureTemplateList, "\n"),
+		TableName:       tableName,
+		Functions:       strings.Join(functionTemplateList, "\n"),
+		StandardImports: standardImports,
+		Imports:         otherImports,
+	}
+	if err := tmpl.Execute(&builder, "repository", repositoryData); err != nil {
+		return "", err
 	}
 	repository = builder.String()
 
-	return repository
+	return repository, nil
 }
 
 // Query to database
-var selectContextTemplate *template.Template = template.Must(
-	template.New("selectContext").Parse("{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
-		"{{ else }}query := `{{.Query}}`{{ end }} \n" +
-		`err := d.db.SelectContext(ctx, &{{.Dest}}, query, {{.Inputs}})
+const selectContextTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`err := d.db.SelectContext(ctx, &{{.Dest}}, query, {{.Inputs}})
 if err != nil {
 	return {{.OutputsWithErr}}
 }
-`))
+`
 
-var getContextTemplate *template.Template = template.Must(
-	template.New("getContext").Parse("{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
-		"{{ else }}query := `{{.Query}}`{{ end }} \n" +
-		`err := d.db.GetContext(ctx, &{{.Dest}}, query, {{.Inputs}})
+const getContextTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`err := d.db.GetContext(ctx, &{{.Dest}}, query, {{.Inputs}})
 if err != nil {
 	if err == sql.ErrNoRows {
 		return {{.OutputsWithNotFoundErr}}
@@ -548,32 +1074,45 @@ if err != nil {
 
 	return {{.OutputsWithErr}}
 }
-`))
+`
 
-var namedExecContextTemplate *template.Template = template.Must(
-	template.New("namedExecContext").Parse("{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
-		"{{ else }}query := `{{.Query}}`{{ end }} \n" +
-		`_, err := d.db.NamedExecContext(ctx, query, {{.Dest}})
+const namedExecContextTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`_, err := d.db.NamedExecContext(ctx, query, {{.Dest}})
 if err != nil {
 	return err
 }
-`))
+`
 
-var execContextTemplate *template.Template = template.Must(
-	template.New("execContext").Parse("{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
-		"{{ else }}query := `{{.Query}}`{{ end }} \n" +
-		`_, err := d.db.ExecContext(ctx, query, {{.ExecVars}})
+const execContextTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`_, err := d.db.ExecContext(ctx, query, {{.ExecVars}})
 if err != nil {
 	return err
 }
-`))
+`
+
+const namedExecContextWithResultTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`result, err := d.db.NamedExecContext(ctx, query, {{.Dest}})
+if err != nil {
+	return 0, err
+}
+`
+
+const execContextWithResultTemplate = "{{ if .SpecialQuery }}query := \"{{.Query}}\"" +
+	"{{ else }}query := `{{.Query}}`{{ end }} \n" +
+	`result, err := d.db.ExecContext(ctx, query, {{.ExecVars}})
+if err != nil {
+	return 0, err
+}
+`
 
 // signature is function's signature
-var signatureTemplate *template.Template = template.Must(
-	template.New("signature").Parse(`{{.FuncName}}(ctx context.Context, {{.Inputs}}) ({{.Outputs}})`))
+const signatureTemplate = `{{.FuncName}}(ctx context.Context, {{.Inputs}}) ({{.Outputs}})`
 
 // function is function's body
-var functionTemplate *template.Template = template.Must(template.New("function").Parse(`
+const functionTemplate = `
 func (d *database{{.ModelName}}) {{.Signature}} {
 	{{.DesStructTemplate}}
 
@@ -583,18 +1122,63 @@ func (d *database{{.ModelName}}) {{.Signature}} {
 
 	return {{.Outputs}}
 }
-`))
+`
 
 // insertFunctionTemplate is function's body for insert methods
-var insertFunctionTemplate *template.Template = template.Must(template.New("function").Parse(`
+const insertFunctionTemplate = `
 func (d *database{{.ModelName}}) {{.Signature}} {
 	{{.ExecQueryTemplate}}
 	return {{.Outputs}}
 }
-`))
+`
+
+// bulkInsertFunctionTemplate is function's body for insert methods with multiple rows.
+// Rows are inserted in batches that fit in the placeholder limit of the dialect.
+const bulkInsertFunctionTemplate = `
+func (d *database{{.ModelName}}) {{.Signature}} {
+	const batchSize = {{.BatchSize}}
+
+	var rowsAffected int64
+	for start := 0; start < len({{.Input}}); start += batchSize {
+		end := start + batchSize
+		if end > len({{.Input}}) {
+			end = len({{.Input}})
+		}
+
+		rows := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*{{.FieldsCount}})
+		for _, {{.Item}} := range {{.Input}}[start:end] {
+			rows = append(rows, {{.Row}})
+			args = append(args, {{.Args}})
+		}
+
+		query := {{.Prefix}} + strings.Join(rows, ", ") + {{.Suffix}}
+		result, err := d.db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return rowsAffected, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return rowsAffected, err
+		}
+		rowsAffected += affected
+	}
+
+	return rowsAffected, nil
+}
+`
+
+// rowsAffectedFunctionTemplate is function's body for methods that return the number of affected rows
+const rowsAffectedFunctionTemplate = `
+func (d *database{{.ModelName}}) {{.Signature}} {
+	{{.ExecQueryTemplate}}
+	return result.RowsAffected()
+}
+`
 
 // repository is file's body
-var repositoryTemplate *template.Template = template.Must(template.New("repository").Parse(`
+const repositoryTemplate = `
 // Code generated by Crafting-Table.
 // Source code: https://github.com/snapp-incubator/crafting-table
 
@@ -603,24 +1187,75 @@ package {{.PackageName}}
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"errors"{{ range .StandardImports }}
+	{{ . }}{{ end }}
 
-	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx"{{ range .Imports }}
+	{{ . }}{{ end }}
 )
 
 type {{.ModelName}} interface {
 	{{.Signatures}}
+	WithTx(tx *sqlx.Tx) {{.ModelName}}
+	RunInTx(ctx context.Context, fn func({{.ModelName}}) error) error
+
+	// ct:custom-begin methods
+	// ct:custom-end
 }
 
 var Err{{.ModelName}}NotFound = errors.New("{{.TableName}} not found")
 
+// {{.ModelName}}Executor is implemented by both *sqlx.DB and *sqlx.Tx.
+type {{.ModelName}}Executor interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
 type database{{.ModelName}} struct {
-	db *sqlx.DB
+	db   {{.ModelName}}Executor
+	conn *sqlx.DB
 }
 
 func New{{.ModelName}}(db *sqlx.DB) {{.ModelName}} {
-	return &database{{.ModelName}}{db: db}
+	return &database{{.ModelName}}{db: db, conn: db}
+}
+
+// WithTx returns a repository that runs its queries in the transaction.
+func (d *database{{.ModelName}}) WithTx(tx *sqlx.Tx) {{.ModelName}} {
+	return &database{{.ModelName}}{db: tx, conn: d.conn}
+}
+
+// RunInTx runs fn with a repository in a new transaction, and commits the transaction if fn returns nil.
+// If the repository is already in a transaction, fn runs in the same transaction.
+func (d *database{{.ModelName}}) RunInTx(ctx context.Context, fn func({{.ModelName}}) error) error {
+	if _, ok := d.db.(*sqlx.Tx); ok {
+		return fn(d)
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(d.WithTx(tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 {{.Functions}}
-`))
+
+// ct:custom-begin functions
+// ct:custom-end
+`
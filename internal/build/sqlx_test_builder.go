@@ -0,0 +1,384 @@
+package build
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+)
+
+type testKind string
+
+const (
+	// testKindGet is for functions that return one row and ErrNotFound
+	testKindGet testKind = "get"
+	// testKindSelect is for functions that return a slice of rows
+	testKindSelect testKind = "select"
+	// testKindExec is for functions that just return an error
+	testKindExec testKind = "exec"
+	// testKindExecWithResult is for functions that return the number of affected rows
+	testKindExecWithResult testKind = "execWithResult"
+)
+
+// TestVariable is an input of a generated function that is filled with faker in tests
+type TestVariable struct {
+	Name string
+	Type string
+}
+
+// functionTest is the data for generating the tests of a generated function
+type functionTest struct {
+	Kind testKind
+	// Variables are declared and filled with fake data before calling the function
+	Variables []TestVariable
+	// Setup is extra code that prepares the inputs of the function
+	Setup string
+	// Call is the list of arguments that is passed to the function after ctx
+	Call string
+	// Query is the query that the function is expected to run
+	Query string
+	// Args is the list of arguments that the query is expected to be run with
+	Args string
+	// Columns is the list of columns that get and select functions read
+	Columns []string
+	// Aggregates is the list of aggregate fields that get and select functions return instead of the model
+	Aggregates []string
+	// RowsAffected is the number of rows that exec functions with result affect in the success case
+	RowsAffected int
+	// SkipNotFound disables the case that exec functions with result affect no rows
+	SkipNotFound bool
+}
+
+// driverName returns the name of the driver that sqlx uses for choosing the bind type of the dialect
+func driverName(dialect DialectType) string {
+	return string(dialect)
+}
+
+// compileNamedQuery converts a named query to the query that sqlx runs for the dialect
+// and returns the names of its arguments in order.
+func compileNamedQuery(dialect DialectType, query string) (string, []string) {
+	// sqlx finds named arguments in the query and returns their values in order,
+	// so the name of each argument is used as its value.
+	names := make(map[string]interface{})
+	for _, part := range strings.Split(query, ":")[1:] {
+		end := strings.IndexFunc(part, func(r rune) bool {
+			return !(r == '_' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'))
+		})
+		if end == -1 {
+			end = len(part)
+		}
+		names[part[:end]] = part[:end]
+	}
+
+	compiled, args, err := sqlx.Named(query, names)
+	if err != nil {
+		return query, nil
+	}
+
+	argNames := make([]string, len(args))
+	for i, arg := range args {
+		argNames[i] = arg.(string)
+	}
+
+	return sqlx.Rebind(sqlx.BindType(driverName(dialect)), compiled), argNames
+}
+
+// objectArgs returns the fields of the object that are passed to a named query in order
+func objectArgs(structure *structure.Structure, object string, columns []string) string {
+	args := make([]string, len(columns))
+	for i, c := range columns {
+		args[i] = object + "." + structure.FieldMapDBFlagToName[c]
+	}
+
+	return strings.Join(args, ", ")
+}
+
+// objectVariable returns the variable that is passed to functions that take the model
+func objectVariable(structure *structure.Structure) TestVariable {
+	return TestVariable{
+		Name: strcase.ToLowerCamel(structure.Name),
+		Type: structure.PackageName + "." + structure.Name,
+	}
+}
+
+// buildFunctionTest builds the tests of a generated function
+func buildFunctionTest(tmpl *templates.Set, structure *structure.Structure, functionName string, test functionTest) (string, error) {
+	if len(test.Columns) == 0 {
+		for _, f := range structure.Fields {
+			test.Columns = append(test.Columns, f.DBFlag)
+		}
+	}
+
+	quotedColumns := make([]string, len(test.Columns))
+	values := make([]string, len(test.Columns))
+	expectedFields := make([]string, len(test.Columns))
+	for i, c := range test.Columns {
+		name := structure.FieldMapDBFlagToName[c]
+		quotedColumns[i] = strconv.Quote(c)
+		values[i] = "row." + name
+		// fields are assigned one by one, since promoted fields of embedded structs can not be set in a literal
+		expectedFields[i] = "expected." + name + " = row." + name
+	}
+
+	var aggregates []TestAggregate
+	if len(test.Aggregates) > 0 {
+		quotedColumns = make([]string, len(test.Aggregates))
+		values = make([]string, len(test.Aggregates))
+		expectedFields = nil
+		for i, a := range test.Aggregates {
+			aggregates = append(aggregates, TestAggregate{Result: "result" + strcase.ToCamel(a), Value: i + 1})
+			quotedColumns[i] = strconv.Quote(a)
+			values[i] = strconv.Itoa(i + 1)
+		}
+	}
+
+	testData := FunctionTestData{
+		ModelName:      structure.Name,
+		Model:          structure.PackageName + "." + structure.Name,
+		FuncName:       functionName,
+		Kind:           test.Kind,
+		Variables:      test.Variables,
+		Setup:          test.Setup,
+		Call:           test.Call,
+		Query:          strconv.Quote(test.Query),
+		Args:           test.Args,
+		Columns:        strings.Join(quotedColumns, ", "),
+		Values:         strings.Join(values, ", "),
+		ExpectedFields: strings.Join(expectedFields, "\n"),
+		Aggregates:     aggregates,
+		RowsAffected:   test.RowsAffected,
+		SkipNotFound:   test.SkipNotFound,
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, "functionTest", testData); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+func BuildTestFile(
+	tmpl *templates.Set,
+	testTemplateList []string,
+	packageName string,
+	modelName string,
+	dialect DialectType,
+	imports map[string]string,
+) (test string, err error) {
+	var builder strings.Builder
+
+	standardImports, otherImports := structure.ImportSpecs(imports)
+
+	testFileData := TestFileData{
+		PackageName:     packageName,
+		ModelName:       modelName,
+		DriverName:      driverName(dialect),
+		Tests:           strings.Join(testTemplateList, "\n"),
+		StandardImports: standardImports,
+		Imports:         otherImports,
+	}
+	if err := tmpl.Execute(&builder, "testFile", testFileData); err != nil {
+		return "", err
+	}
+	test = builder.String()
+
+	return test, nil
+}
+
+// testCall is the call of the function under test in every test case
+const testCall = `{{define "call"}}{{ if eq .Kind "exec" }}err{{ else if .Aggregates }}{{ range .Aggregates }}{{.Result}}, ` +
+	`{{ end }}err{{ else }}result, err{{ end }} := ` +
+	`repo.{{.FuncName}}(context.Background(){{ if .Call }}, {{.Call}}{{ end }}){{end}}`
+
+// testSetup declares the inputs of the function under test in every test case
+const testSetup = `{{define "setup"}}repo, mock := new{{.ModelName}}Mock(t)
+{{ range .Variables }}
+		var {{.Name}} {{.Type}}
+		require.NoError(t, faker.FakeData(&{{.Name}}))
+{{ end }}
+		{{.Setup}}{{end}}`
+
+// functionTestTemplate is the test of a function with success, not found and driver error cases
+const functionTestTemplate = testCall + testSetup + `
+func Test{{.ModelName}}_{{.FuncName}}(t *testing.T) {
+{{- if or (eq .Kind "get") (eq .Kind "select") }}
+	t.Run("success", func(t *testing.T) {
+		{{ template "setup" . }}
+{{ if not .Aggregates }}
+		var row {{.Model}}
+		require.NoError(t, faker.FakeData(&row))
+		var expected {{.Model}}
+		{{.ExpectedFields}}
+{{ end }}
+		rows := sqlmock.NewRows([]string{ {{.Columns}} }).AddRow({{.Values}})
+		mock.ExpectQuery({{.Query}}).WithArgs({{.Args}}).WillReturnRows(rows)
+
+		{{ template "call" . }}
+		require.NoError(t, err)
+		{{- range .Aggregates }}
+		{{ if eq $.Kind "get" }}require.Equal(t, {{.Value}}, *{{.Result}}){{ else }}require.Equal(t, []int{ {{.Value}} }, {{.Result}}){{ end }}
+		{{- else }}
+		{{ if eq .Kind "get" }}require.Equal(t, &expected, result){{ else }}require.Equal(t, []{{.Model}}{expected}, result){{ end }}
+		{{- end }}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		{{ template "setup" . }}
+
+		{{ if eq .Kind "get" -}}
+		mock.ExpectQuery({{.Query}}).WithArgs({{.Args}}).WillReturnError(sql.ErrNoRows)
+
+		{{ template "call" . }}
+		require.ErrorIs(t, err, Err{{.ModelName}}NotFound)
+		{{- range .Aggregates }}
+		require.Nil(t, {{.Result}})
+		{{- else }}
+		require.Nil(t, result)
+		{{- end }}
+		{{- else -}}
+		mock.ExpectQuery({{.Query}}).WithArgs({{.Args}}).WillReturnRows(sqlmock.NewRows([]string{ {{.Columns}} }))
+
+		{{ template "call" . }}
+		require.NoError(t, err)
+		{{- range .Aggregates }}
+		require.Empty(t, {{.Result}})
+		{{- else }}
+		require.Empty(t, result)
+		{{- end }}
+		{{- end }}
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+{{ else if eq .Kind "exec" }}
+	t.Run("success", func(t *testing.T) {
+		{{ template "setup" . }}
+
+		mock.ExpectExec({{.Query}}).WithArgs({{.Args}}).WillReturnResult(sqlmock.NewResult(1, 1))
+
+		{{ template "call" . }}
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+{{ else if eq .Kind "execWithResult" }}
+	t.Run("success", func(t *testing.T) {
+		{{ template "setup" . }}
+
+		mock.ExpectExec({{.Query}}).WithArgs({{.Args}}).WillReturnResult(sqlmock.NewResult(0, {{.RowsAffected}}))
+
+		{{ template "call" . }}
+		require.NoError(t, err)
+		require.Equal(t, int64({{.RowsAffected}}), result)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+{{ if not .SkipNotFound }}
+	t.Run("not found", func(t *testing.T) {
+		{{ template "setup" . }}
+
+		mock.ExpectExec({{.Query}}).WithArgs({{.Args}}).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		{{ template "call" . }}
+		require.NoError(t, err)
+		require.Zero(t, result)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+{{ end }}{{ end }}
+	t.Run("driver error", func(t *testing.T) {
+		{{ template "setup" . }}
+
+		{{ if or (eq .Kind "exec") (eq .Kind "execWithResult") -}}
+		mock.ExpectExec({{.Query}}).WithArgs({{.Args}}).WillReturnError(err{{.ModelName}}Driver)
+		{{- else -}}
+		mock.ExpectQuery({{.Query}}).WithArgs({{.Args}}).WillReturnError(err{{.ModelName}}Driver)
+		{{- end }}
+
+		{{ if eq .Kind "exec" }}err{{ else }}{{ range .Aggregates }}_, {{ else }}_, {{ end }}err{{ end }} := repo.{{.FuncName}}(context.Background(){{ if .Call }}, {{.Call}}{{ end }})
+		require.ErrorIs(t, err, err{{.ModelName}}Driver)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+`
+
+// testFileTemplate is test file's body
+const testFileTemplate = `
+// Code generated by Crafting-Table.
+// Source code: https://github.com/snapp-incubator/crafting-table
+
+package {{.PackageName}}
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"{{ range .StandardImports }}
+	{{ . }}{{ end }}
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bxcodec/faker/v3"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"{{ range .Imports }}
+	{{ . }}{{ end }}
+)
+
+var err{{.ModelName}}Driver = errors.New("driver error")
+
+// new{{.ModelName}}Mock creates a repository on a new sqlmock for each test case
+func new{{.ModelName}}Mock(t *testing.T) ({{.ModelName}}, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return New{{.ModelName}}(sqlx.NewDb(db, "{{.DriverName}}")), mock
+}
+
+func Test{{.ModelName}}_RunInTx(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		repo, mock := new{{.ModelName}}Mock(t)
+
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := repo.RunInTx(context.Background(), func({{.ModelName}}) error {
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		repo, mock := new{{.ModelName}}Mock(t)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := repo.RunInTx(context.Background(), func({{.ModelName}}) error {
+			return err{{.ModelName}}Driver
+		})
+		require.ErrorIs(t, err, err{{.ModelName}}Driver)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+{{.Tests}}
+`
+
+// bulkTestSetup creates the slice that is passed to bulk insert functions in tests
+func bulkTestSetup(structure *structure.Structure, input string, count int) string {
+	return fmt.Sprintf(`%s := make([]*%s.%s, %d)
+		for i := range %s {
+			%s[i] = new(%s.%s)
+			require.NoError(t, faker.FakeData(%s[i]))
+		}`,
+		input, structure.PackageName, structure.Name, count,
+		input,
+		input, structure.PackageName, structure.Name,
+		input,
+	)
+}
@@ -0,0 +1,171 @@
+package build
+
+import (
+	"text/template"
+
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+)
+
+// DefaultTemplates are the templates of generated repositories and their tests. The data of each template is:
+//   - repository: RepositoryData
+//   - signature: SignatureData
+//   - function, insertFunction and rowsAffectedFunction: FunctionData
+//   - bulkInsertFunction: BulkInsertFunctionData
+//   - getContext, selectContext, namedExecContext, execContext, namedExecContextWithResult and
+//     execContextWithResult: QueryData
+//   - testFile: TestFileData
+//   - functionTest, and the call and setup templates that it defines: FunctionTestData
+var DefaultTemplates = templates.Must(
+	templates.Template{Name: "repository", Source: repositoryTemplate},
+	templates.Template{Name: "signature", Source: signatureTemplate},
+	templates.Template{Name: "function", Source: functionTemplate},
+	templates.Template{Name: "insertFunction", Source: insertFunctionTemplate},
+	templates.Template{Name: "bulkInsertFunction", Source: bulkInsertFunctionTemplate},
+	templates.Template{Name: "rowsAffectedFunction", Source: rowsAffectedFunctionTemplate},
+	templates.Template{Name: "getContext", Source: getContextTemplate},
+	templates.Template{Name: "selectContext", Source: selectContextTemplate},
+	templates.Template{Name: "namedExecContext", Source: namedExecContextTemplate},
+	templates.Template{Name: "execContext", Source: execContextTemplate},
+	templates.Template{Name: "namedExecContextWithResult", Source: namedExecContextWithResultTemplate},
+	templates.Template{Name: "execContextWithResult", Source: execContextWithResultTemplate},
+	templates.Template{Name: "testFile", Source: testFileTemplate},
+	templates.Template{Name: "functionTest", Source: functionTestTemplate},
+)
+
+// LoadTemplates returns the default templates of repositories with the templates of a directory and the extra
+// functions, see templates.Override.
+func LoadTemplates(dir string, funcs template.FuncMap) (*templates.Set, error) {
+	sets, err := templates.Override(dir, funcs, DefaultTemplates)
+	if err != nil {
+		return nil, err
+	}
+	return sets[0], nil
+}
+
+// RepositoryData is the data of the repository template, which is the whole repository file.
+type RepositoryData struct {
+	PackageName string
+	// ModelName is the name of the struct, which is the name of the repository interface as well
+	ModelName string
+	TableName string
+	// Signatures are the methods of the repository interface, one per line
+	Signatures string
+	// Functions are the generated methods of the repository
+	Functions string
+	// StandardImports and Imports are the quoted import paths of the types of the struct fields, with their names
+	// if they are needed
+	StandardImports []string
+	Imports         []string
+}
+
+// SignatureData is the data of the signature template, which is the signature of a method without func.
+type SignatureData struct {
+	FuncName string
+	// Inputs are the parameters after ctx, and Outputs are the results, e.g. "id int64" and "*models.User, error"
+	Inputs  string
+	Outputs string
+}
+
+// QueryData is the data of the templates that run a query in a method, e.g. getContext.
+type QueryData struct {
+	// FuncName is the name of the method, e.g. to wrap its errors
+	FuncName string
+	// Query is the query, and SpecialQuery reports whether it is quoted by "" instead of ``, since it has `
+	Query        string
+	SpecialQuery bool
+	// Dest is the variable that the rows are scanned into, or the struct of a named query
+	Dest string
+	// Inputs are the arguments of get and select queries, and ExecVars are the arguments of exec queries
+	Inputs   string
+	ExecVars string
+	// OutputsWithErr and OutputsWithNotFoundErr are the results that the method returns on an error, and when
+	// there is no row
+	OutputsWithErr         string
+	OutputsWithNotFoundErr string
+}
+
+// FunctionData is the data of the function, insertFunction and rowsAffectedFunction templates, which are methods of
+// the repository.
+type FunctionData struct {
+	ModelName string
+	FuncName  string
+	// Query is the query that the method runs
+	Query string
+	// Signature is the result of the signature template
+	Signature string
+	// DesStructTemplate is the struct that the aggregate fields are scanned into, and DstModel is the type of dst
+	DesStructTemplate string
+	DstModel          string
+	// ExecQueryTemplate is the result of the template that runs the query, e.g. getContext
+	ExecQueryTemplate string
+	// Outputs are the results that the method returns on success
+	Outputs string
+}
+
+// BulkInsertFunctionData is the data of the bulkInsertFunction template, which inserts the rows in batches.
+type BulkInsertFunctionData struct {
+	ModelName string
+	FuncName  string
+	Signature string
+	BatchSize int
+	// Input is the slice of the rows, and Item is the variable of a row
+	Input string
+	Item  string
+	// FieldsCount is the number of the inserted fields of a row, and Args are the fields of Item
+	FieldsCount int
+	Args        string
+	// Prefix and Suffix are the quoted parts of the query before and after the rows, and Row is the expression of
+	// the values of a row
+	Prefix string
+	Row    string
+	Suffix string
+}
+
+// TestFileData is the data of the testFile template, which is the whole test file of a repository.
+type TestFileData struct {
+	PackageName string
+	ModelName   string
+	// DriverName is the name of the driver that sqlx chooses the placeholders of the dialect by
+	DriverName string
+	// Tests are the results of the functionTest template
+	Tests           string
+	StandardImports []string
+	Imports         []string
+}
+
+// FunctionTestData is the data of the functionTest template, which tests a method of the repository with sqlmock.
+type FunctionTestData struct {
+	ModelName string
+	// Model is the qualified type of the struct, e.g. models.User
+	Model    string
+	FuncName string
+	// Kind is one of get, select, exec and execWithResult
+	Kind testKind
+	// Variables are declared and filled with fake data before calling the method, and Setup is extra code that
+	// prepares the inputs
+	Variables []TestVariable
+	Setup     string
+	// Call is the arguments of the method after ctx
+	Call string
+	// Query is the quoted query that the method is expected to run, with Args
+	Query string
+	Args  string
+	// Columns are the quoted columns of get and select methods, Values are the fields of row for them, and
+	// ExpectedFields assigns the fields of row to expected
+	Columns        string
+	Values         string
+	ExpectedFields string
+	// Aggregates are the results of get and select methods with aggregate fields, which return them instead of the
+	// model
+	Aggregates []TestAggregate
+	// RowsAffected is the result of exec methods with result, and SkipNotFound disables their not found case
+	RowsAffected int
+	SkipNotFound bool
+}
+
+// TestAggregate is an aggregate field that a method returns, with the variable of the result and the value of its
+// column in the success case
+type TestAggregate struct {
+	Result string
+	Value  int
+}
@@ -0,0 +1,579 @@
+package build
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	internalStruct "github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// enumValues are the values of the string types of the manifest
+var enumValues = map[reflect.Type][]string{
+	reflect.TypeOf(DialectType("")): {string(MySQL), string(Postgres), string(SQLite3), string(SQLServer)},
+	reflect.TypeOf(SelectType("")):  {string(SelectTypeSelect), string(SelectTypeGet)},
+	reflect.TypeOf(OrderType("")):   {string(OrderTypeAsc), string(OrderTypeDesc)},
+	reflect.TypeOf(OperatorType("")): {
+		string(OperatorTypeEqual), string(OperatorTypeNotEqual), string(OperatorTypeIn), string(OperatorTypeNotIn),
+		string(OperatorTypeGt), string(OperatorTypeGte), string(OperatorTypeLt), string(OperatorTypeLte),
+		string(OperatorTypeIsNull), string(OperatorTypeIsNotNull),
+	},
+	reflect.TypeOf(internalStruct.TableNaming("")): {
+		string(internalStruct.SnakeCase), string(internalStruct.PluralSnakeCase),
+	},
+	reflect.TypeOf(JoinType("")): {
+		string(JoinTypeJoin), string(JoinTypeInner), string(JoinTypeFullOuter), string(JoinTypeRightOuter),
+		string(JoinTypeLeftOuter), string(JoinTypeFull), string(JoinTypeLeft), string(JoinTypeRight),
+		string(JoinTypeNatural), string(JoinTypeNaturalLeft), string(JoinTypeNaturalRight),
+		string(JoinTypeNaturalFull), string(JoinTypeCross),
+	},
+	reflect.TypeOf(ConflictActionType("")): {string(ConflictActionDoNothing), string(ConflictActionUpdate)},
+}
+
+// EnumValues returns the values of a string type of the manifest, e.g. a DialectType, or nil if the type is not an
+// enum.
+func EnumValues(v interface{}) []string {
+	return enumValues[reflect.TypeOf(v)]
+}
+
+// requiredFields are the yaml keys that the types of the manifest must have
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeOf(Repo{}):           {"source", "destination", "dialect", "package_name"},
+	reflect.TypeOf(Select{}):         {"type"},
+	reflect.TypeOf(AggregateField{}): {"function", "on", "as"},
+	reflect.TypeOf(WhereCondition{}): {"column", "operator"},
+	reflect.TypeOf(JoinField{}):      {"table", "function"},
+	reflect.TypeOf(Insert{}):         {"fields"},
+	reflect.TypeOf(Update{}):         {"fields"},
+}
+
+// reservedFunctionNames are the methods that every repository has
+var reservedFunctionNames = []string{"WithTx", "RunInTx"}
+
+// ValidateManifest checks a manifest file without generating it. Keys that the manifest does not have, values that
+// are not of their enums or types, missing required fields and functions with the same name are returned together
+// as Diagnostics. Errors of reading the file and yaml syntax errors are returned as is. The fields that the defaults
+// set are not required.
+func ValidateManifest(path string, defaults Defaults) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	v := validator{path: path, defaults: defaults}
+	d := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		if err := d.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+
+		if !isRepositoryList(&document) {
+			v.validateRepo(documentRoot(&document))
+			continue
+		}
+
+		// the repositories are validated one by one, so their problems have their destinations
+		root := documentRoot(&document)
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			key, value := root.Content[i], resolveAlias(root.Content[i+1])
+			if key.Value != "repositories" {
+				v.report(key, key.Value, "", nil, "unknown field %q", key.Value)
+				continue
+			}
+			if value.Kind != yaml.SequenceNode {
+				v.report(value, "repositories", "", nil, "repositories must be a list")
+				continue
+			}
+			for _, repoNode := range value.Content {
+				v.validateRepo(resolveAlias(repoNode))
+			}
+		}
+	}
+
+	if len(v.diagnostics) > 0 {
+		sort.SliceStable(v.diagnostics, func(i, j int) bool {
+			return v.diagnostics[i].Line < v.diagnostics[j].Line
+		})
+		return v.diagnostics
+	}
+	return nil
+}
+
+type validator struct {
+	path        string
+	defaults    Defaults
+	diagnostics Diagnostics
+}
+
+// validationPath is the path of a value in a repository, of keys and indexes, e.g. ["select", 0, "type"]
+type validationPath []interface{}
+
+func (p validationPath) with(element interface{}) validationPath {
+	return append(append(validationPath{}, p...), element)
+}
+
+// entry returns the function entry of the path, e.g. "select[0]", or "" for the values of the repository.
+func (p validationPath) entry() string {
+	if len(p) >= 2 {
+		if index, ok := p[1].(int); ok {
+			return fmt.Sprintf("%s[%d]", p[0], index)
+		}
+	}
+	return ""
+}
+
+// repoContext is the repository whose problems are reported, with the names of the functions of its entries, and
+// the entries that have values which are not of their types or enums, e.g. "insert[0]", or "" for the repository.
+type repoContext struct {
+	node        *yaml.Node
+	destination string
+	functions   map[string]string
+	invalid     map[string]bool
+}
+
+func (v *validator) validateRepo(node *yaml.Node) {
+	repo := &repoContext{node: node, invalid: make(map[string]bool)}
+	if destination := mappingValue(node, "destination"); destination != nil {
+		repo.destination = destination.Value
+	}
+
+	// keys that are not known are skipped by Decode, and the values that are not of their types are reported by walk
+	// and left empty, so the functions are validated with the other values
+	r := v.defaults.repo()
+	var typeError *yaml.TypeError
+	if err := node.Decode(&r); err != nil && !errors.As(err, &typeError) {
+		v.walk(repo, node, reflect.TypeOf(Repo{}), nil)
+		return
+	}
+
+	repo.functions = make(map[string]string)
+	for _, entry := range entriesOf(r) {
+		repo.functions[entry.label] = entry.function
+	}
+
+	v.walk(repo, node, reflect.TypeOf(Repo{}), nil)
+	v.validateFunctions(repo, r)
+}
+
+// walk reports the keys that the type does not have, the missing required keys, and the values that are not of the
+// type or its enum.
+func (v *validator) walk(repo *repoContext, node *yaml.Node, t reflect.Type, path validationPath) {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node == nil || node.Tag == "!!null" {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			v.reportAt(repo, node, path, "must be a mapping")
+			return
+		}
+
+		fields := yamlFields(t)
+		present := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := fields[key.Value]
+			if !ok && t == reflect.TypeOf(Repo{}) && generator.Lookup(key.Value) != nil {
+				v.walkSection(repo, node.Content[i+1], path.with(key.Value))
+				continue
+			}
+			if !ok {
+				v.reportAt(repo, key, path.with(key.Value), "unknown field %q", key.Value)
+				continue
+			}
+
+			value := resolveAlias(node.Content[i+1])
+			if !isEmpty(value) {
+				present[key.Value] = true
+			}
+			v.walk(repo, value, field.Type, path.with(key.Value))
+		}
+
+		for _, key := range requiredFields[t] {
+			if !present[key] && !(t == reflect.TypeOf(Repo{}) && v.defaults.sets(key)) {
+				v.reportAt(repo, node, path, "%s is required", key)
+			}
+		}
+
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			v.reportAt(repo, node, path, "must be a list")
+			return
+		}
+		for i, item := range node.Content {
+			v.walk(repo, item, t.Elem(), path.with(i))
+		}
+
+	default:
+		if node.Kind != yaml.ScalarNode {
+			v.reportAt(repo, node, path, "must be a %s", t.Kind())
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			repo.invalid[path.entry()] = true
+			v.reportAt(repo, node, path, "%s", typeErrorMessage(err))
+			return
+		}
+
+		if values, ok := enumValues[t]; ok && node.Value != "" && !hasString(values, node.Value) {
+			repo.invalid[path.entry()] = true
+			v.reportAt(repo, node, path, "%q is not one of %s", node.Value, strings.Join(values, ", "))
+		}
+	}
+}
+
+// walkSection reports the section of a registered generator if it is not a list of mappings. The keys of its
+// entries are checked by its generator.
+func (v *validator) walkSection(repo *repoContext, node *yaml.Node, path validationPath) {
+	node = resolveAlias(node)
+	if node == nil || node.Tag == "!!null" {
+		return
+	}
+	if node.Kind != yaml.SequenceNode {
+		v.reportAt(repo, node, path, "must be a list")
+		return
+	}
+	for i, item := range node.Content {
+		if item = resolveAlias(item); item.Kind != yaml.MappingNode {
+			v.reportAt(repo, item, path.with(i), "must be a mapping")
+		}
+	}
+}
+
+// validateFunctions reports the problems of the values that depend on each other, and the functions with the same
+// name. The values that depend on a value which is not of its type or enum are not checked, e.g. the columns of
+// on_conflict with a dialect that is not known, since its problem is already reported.
+func (v *validator) validateFunctions(repo *repoContext, r Repo) {
+	for i, sel := range r.Select {
+		entry := validationPath{"select", i}
+		if sel.OrderBy != "" && sel.OrderType == "" {
+			v.reportPath(repo, entry.with("order_by"), "order_type is required with order_by")
+		}
+		for j, aggregate := range sel.AggregateFields {
+			if _, ok := setAggregate[strings.ToUpper(aggregate.Function)]; !ok && aggregate.Function != "" {
+				v.reportPath(repo, entry.with("aggregate_fields").with(j).with("function"),
+					"%q is not one of %s", aggregate.Function, strings.Join(aggregateFunctions, ", "))
+			}
+		}
+		for j, join := range sel.JoinFields {
+			if !repo.invalid[entry.entry()] && joinHasCondition(join.Function) &&
+				(join.OnSource == "" || join.OnJoin == "") {
+				v.reportPath(repo, entry.with("join_fields").with(j),
+					"on_source and on_join are required for %s join", join.Function)
+			}
+		}
+	}
+
+	for i, insert := range r.Insert {
+		entry := validationPath{"insert", i}
+		if repo.invalid[""] || repo.invalid[entry.entry()] {
+			continue
+		}
+		if insert.OnConflict == nil || len(insert.OnConflict.Columns) > 0 {
+			continue
+		}
+		if r.Dialect == SQLServer ||
+			(r.Dialect != MySQL && insert.OnConflict.Action != ConflictActionDoNothing) {
+			v.reportPath(repo, entry.with("on_conflict"),
+				"columns of on_conflict are required for %s action in %s", conflictAction(insert.OnConflict), r.Dialect)
+		}
+	}
+
+	for i, del := range r.Delete {
+		if len(del.WhereConditions) == 0 && !del.AllRows {
+			v.reportPath(repo, validationPath{"delete", i},
+				"delete without where conditions deletes every row, set all_rows to generate it")
+		}
+		if len(del.WhereConditions) > 0 && del.AllRows {
+			v.reportPath(repo, validationPath{"delete", i, "all_rows"}, "all_rows cannot be set with where conditions")
+		}
+	}
+
+	generatedBy := make(map[string]string)
+	for _, entry := range entriesOf(r) {
+		if repo.invalid[entry.label] {
+			continue
+		}
+
+		field := entry.path
+		if entry.custom {
+			field = field.with("function_name")
+		}
+
+		if hasString(reservedFunctionNames, entry.function) {
+			v.reportPath(repo, field, "function %s is a method of every repository", entry.function)
+		} else if label, ok := generatedBy[entry.function]; ok {
+			v.reportPath(repo, field, "function %s is also generated by %s", entry.function, label)
+		} else {
+			generatedBy[entry.function] = entry.label
+		}
+	}
+}
+
+// checkValues returns the diagnostics of the values of a repository that it cannot be generated without, with the
+// messages of ValidateManifest: a missing dialect, a missing order_type or join function, and the values of dialect,
+// table_naming, order_type and join functions that are not of their enums.
+func checkValues(repo Repo) Diagnostics {
+	var diagnostics Diagnostics
+	add := func(section string, index int, function string, err error) {
+		if err != nil {
+			diagnostics = append(diagnostics, diagnosticsOf(repo, section, index, function, err)...)
+		}
+	}
+
+	if repo.Dialect == "" {
+		add("", 0, "", errors.New("dialect is required"))
+	}
+	add("", 0, "", enumError("dialect", repo.Dialect))
+	add("", 0, "", enumError("table_naming", repo.TableNaming))
+
+	functions := make(map[string]string)
+	for _, entry := range entriesOf(repo) {
+		functions[entry.label] = entry.function
+	}
+	for i, sel := range repo.Select {
+		function := functions[fmt.Sprintf("select[%d]", i)]
+		if sel.OrderBy != "" && sel.OrderType == "" {
+			add("select", i, function, &FieldError{Field: "order_by", Message: "order_type is required with order_by"})
+		}
+		add("select", i, function, enumError("order_type", sel.OrderType))
+		for j, join := range sel.JoinFields {
+			field := fmt.Sprintf("join_fields[%d]", j)
+			if join.Function == "" {
+				add("select", i, function, &FieldError{Field: field, Message: "function is required"})
+			}
+			add("select", i, function, enumError(field+".function", join.Function))
+		}
+	}
+
+	return diagnostics
+}
+
+// enumError returns the problem of a value of a field that is not one of the values of its enum, or nil.
+func enumError(field string, value interface{}) error {
+	s := reflect.ValueOf(value).String()
+	values := EnumValues(value)
+	if s == "" || hasString(values, s) {
+		return nil
+	}
+	return &FieldError{Field: field, Value: s, Message: fmt.Sprintf("%q is not one of %s", s, strings.Join(values, ", "))}
+}
+
+// reportPath reports a problem at the node of the path, or the closest node that the repository has.
+func (v *validator) reportPath(repo *repoContext, path validationPath, format string, args ...interface{}) {
+	node := repo.node
+	for _, element := range path {
+		var next *yaml.Node
+		switch e := element.(type) {
+		case string:
+			next = mappingValue(node, e)
+		case int:
+			next = sequenceItem(node, e)
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+
+	v.reportAt(repo, node, path, format, args...)
+}
+
+// reportAt reports a problem of a repository at the line of the node. The entry of the problem is the first two
+// elements of the path, e.g. select[0], and its field is the rest of the path.
+func (v *validator) reportAt(repo *repoContext, node *yaml.Node, path validationPath, format string, args ...interface{}) {
+	entry := ""
+	if len(path) >= 2 {
+		if index, ok := path[1].(int); ok {
+			entry = fmt.Sprintf("%s[%d]", path[0], index)
+			path = path[2:]
+		}
+	}
+
+	var field strings.Builder
+	for _, element := range path {
+		switch e := element.(type) {
+		case string:
+			if field.Len() > 0 {
+				field.WriteString(".")
+			}
+			field.WriteString(e)
+		case int:
+			_, _ = fmt.Fprintf(&field, "[%d]", e)
+		}
+	}
+
+	v.report(node, field.String(), entry, repo, format, args...)
+}
+
+func (v *validator) report(node *yaml.Node, field, entry string, repo *repoContext, format string, args ...interface{}) {
+	d := Diagnostic{
+		Manifest: v.path,
+		Line:     node.Line,
+		Entry:    entry,
+		Field:    field,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if repo != nil {
+		d.Repository = repo.destination
+		d.Function = repo.functions[entry]
+	}
+
+	v.diagnostics = append(v.diagnostics, d)
+}
+
+// functionEntry is a function entry of a repository with the name of its function.
+type functionEntry struct {
+	path     validationPath
+	label    string
+	function string
+	custom   bool
+}
+
+// entriesOf returns the function entries of a repository in the order that they are generated.
+func entriesOf(r Repo) []functionEntry {
+	var entries []functionEntry
+	add := func(section string, index int, customFunctionName, prefix string, where []WhereCondition) {
+		entries = append(entries, functionEntry{
+			path:     validationPath{section, index},
+			label:    fmt.Sprintf("%s[%d]", section, index),
+			function: functionNameOf(customFunctionName, prefix, where),
+			custom:   customFunctionName != "",
+		})
+	}
+
+	for i, sel := range r.Select {
+		prefix := "Select"
+		if sel.Type == SelectTypeGet {
+			prefix = "Get"
+		}
+		add("select", i, sel.FunctionName, prefix, sel.WhereConditions)
+	}
+	for i, insert := range r.Insert {
+		prefix := "Create"
+		if insert.Bulk {
+			prefix = "CreateMany"
+		}
+		add("insert", i, insert.FunctionName, prefix, nil)
+	}
+	for i, update := range r.Update {
+		add("update", i, update.FunctionName, "Update", update.WhereConditions)
+	}
+	for i, del := range r.Delete {
+		add("delete", i, del.FunctionName, "Delete", del.WhereConditions)
+	}
+
+	return entries
+}
+
+// yamlField is a field of a struct of the manifest by its yaml key.
+type yamlField struct {
+	Name string
+	Type reflect.Type
+}
+
+// yamlFields returns the fields of a struct by their yaml keys, in the order of the struct.
+func yamlFields(t reflect.Type) map[string]yamlField {
+	fields := make(map[string]yamlField)
+	for _, field := range orderedYAMLFields(t) {
+		fields[field.Name] = field
+	}
+	return fields
+}
+
+func orderedYAMLFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		options := strings.Split(field.Tag.Get("yaml"), ",")
+		name := options[0]
+		// inline fields are the keys of other types, e.g. the sections of the registered generators
+		if name == "-" || hasString(options[1:], "inline") {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields = append(fields, yamlField{Name: name, Type: field.Type})
+	}
+	return fields
+}
+
+// aggregateFunctions are the aggregate functions in the order of the documentation, which are case-insensitive
+var aggregateFunctions = []string{"COUNT", "SUM", "AVG", "MAX", "MIN", "FIRST", "LAST"}
+
+// joinHasCondition reports whether a join function joins on the columns of on_source and on_join.
+func joinHasCondition(function JoinType) bool {
+	switch function {
+	case JoinTypeNatural, JoinTypeNaturalLeft, JoinTypeNaturalRight, JoinTypeNaturalFull, JoinTypeCross, "":
+		return false
+	}
+	return true
+}
+
+func conflictAction(onConflict *OnConflict) ConflictActionType {
+	if onConflict.Action == "" {
+		return ConflictActionUpdate
+	}
+	return onConflict.Action
+}
+
+// lineNumber is the line number that yaml adds to the messages of its type errors
+var lineNumber = regexp.MustCompile(`^line \d+: `)
+
+// typeErrorMessage returns the message of a yaml type error without its line number, which is reported separately.
+func typeErrorMessage(err error) string {
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) && len(typeError.Errors) > 0 {
+		return lineNumber.ReplaceAllString(typeError.Errors[0], "")
+	}
+	return err.Error()
+}
+
+// isEmpty reports whether a yaml value is null, an empty string or an empty list.
+func isEmpty(node *yaml.Node) bool {
+	if node == nil || node.Tag == "!!null" {
+		return true
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value == ""
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
+	}
+	return false
+}
+
+func hasString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
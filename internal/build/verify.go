@@ -0,0 +1,38 @@
+package build
+
+import "strings"
+
+// Statement is the query of a generated function.
+type Statement struct {
+	FunctionName string
+	Query        string
+}
+
+// Verifier checks the queries of a repository before it is written, e.g. against the schema of its tables. The
+// statements that do not verify are returned as StatementErrors, and other errors stop the verification, e.g. a
+// schema that can not be parsed.
+type Verifier interface {
+	Verify(repo Repo, statements []Statement) error
+}
+
+// StatementError is the error of a statement that does not verify. Index is the index of the statement in the
+// verified statements.
+type StatementError struct {
+	Index int
+	Err   error
+}
+
+func (e StatementError) Error() string {
+	return e.Err.Error()
+}
+
+// StatementErrors are the errors of the statements that do not verify.
+type StatementErrors []StatementError
+
+func (e StatementErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
@@ -0,0 +1,308 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/ddl"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+)
+
+// Problem is a reference of the manifest that does not match the schema. File is the Go file of the problems of
+// struct fields, or empty for the problems of the manifest, and Line is the line of the problem in it.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// Check resolves the tables and columns that the repositories of a manifest refer to in the tables of a schema,
+// and compares the types of the struct fields with the types of their columns. Problems of the manifest are sorted by
+// line, followed by the problems of struct fields, which are sorted by file and line.
+func Check(manifest *build.Manifest, tables []*ddl.Table, dialect build.DialectType) []Problem {
+	c := checker{tables: tables, dialect: dialect}
+	for i, repo := range manifest.Repos {
+		c.checkRepo(repo, manifest.RepoNode(i))
+	}
+
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		if c.problems[i].File != c.problems[j].File {
+			return c.problems[i].File < c.problems[j].File
+		}
+		return c.problems[i].Line < c.problems[j].Line
+	})
+
+	return c.problems
+}
+
+type checker struct {
+	tables   []*ddl.Table
+	dialect  build.DialectType
+	problems []Problem
+	// repoNode is the node of the checked repository, whose line is reported when a value has no node
+	repoNode *yaml.Node
+}
+
+func (c *checker) report(node *yaml.Node, format string, args ...interface{}) {
+	if node == nil {
+		node = c.repoNode
+	}
+	line := 0
+	if node != nil {
+		line = node.Line
+	}
+	c.problems = append(c.problems, Problem{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// reportField reports a problem of a struct field at its declaration, or at the node if its position is not known.
+func (c *checker) reportField(field structure.Field, node *yaml.Node, format string, args ...interface{}) {
+	if !field.Position.IsValid() {
+		c.report(node, format, args...)
+		return
+	}
+
+	c.problems = append(c.problems, Problem{
+		File:    relativePath(field.Position.Filename),
+		Line:    field.Position.Line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// table returns the table with the name, that may be qualified by a schema in either the manifest or the schema.
+func (c *checker) table(name string) *ddl.Table {
+	for _, table := range c.tables {
+		if strings.EqualFold(table.Name, name) || strings.EqualFold(unqualified(table.Name), unqualified(name)) {
+			return table
+		}
+	}
+	return nil
+}
+
+func (c *checker) checkRepo(repo build.Repo, node *yaml.Node) {
+	c.repoNode = node
+
+	s, err := structure.BindStruct(repo.Source, repo.StructName)
+	if err != nil {
+		c.report(child(node, "source"), "%s", err)
+		return
+	}
+
+	tableName := repo.TableNameOf(s)
+	tableNode := child(node, "struct_name")
+	if repo.TableName != "" {
+		tableNode = child(node, "table_name")
+	} else if namingNode := child(node, "table_naming"); namingNode != nil {
+		tableNode = namingNode
+	}
+
+	table := c.table(tableName)
+	if table == nil {
+		c.report(tableNode, "table %s is not found in the schema", tableName)
+		return
+	}
+
+	c.checkFields(s, table, child(node, "source"))
+
+	repoScope := newScope(table)
+	repoScope.resolve(c, repo.SoftDeleteColumn, child(node, "soft_delete_column"))
+
+	for i, sel := range repo.Select {
+		selectNode := item(child(node, "select"), i)
+		selectScope := newScope(table)
+
+		for j, join := range sel.JoinFields {
+			joinNode := item(child(selectNode, "join_fields"), j)
+			joinTable := c.table(join.Table)
+			if joinTable == nil {
+				c.report(child(joinNode, "table"), "table %s is not found in the schema", join.Table)
+				continue
+			}
+
+			selectScope.join(join, joinTable)
+			if join.OnSource != "" && table.Column(join.OnSource) == nil {
+				c.report(child(joinNode, "on_source"), "column %s is not found in table %s", join.OnSource, table.Name)
+			}
+			if join.OnJoin != "" && joinTable.Column(join.OnJoin) == nil {
+				c.report(child(joinNode, "on_join"), "column %s is not found in table %s", join.OnJoin, joinTable.Name)
+			}
+		}
+
+		selectScope.resolveList(c, sel.Fields, child(selectNode, "fields"))
+		selectScope.resolveConditions(c, sel.WhereConditions, child(selectNode, "where_conditions"))
+		for j, aggregate := range sel.AggregateFields {
+			if aggregate.On != "*" {
+				selectScope.resolve(c, aggregate.On, child(item(child(selectNode, "aggregate_fields"), j), "on"))
+			}
+		}
+		selectScope.resolve(c, sel.OrderBy, child(selectNode, "order_by"))
+		selectScope.resolveList(c, sel.GroupBy, child(selectNode, "group_by"))
+	}
+
+	for i, insert := range repo.Insert {
+		insertNode := item(child(node, "insert"), i)
+		repoScope.resolveList(c, insert.Fields, child(insertNode, "fields"))
+		if insert.OnConflict != nil {
+			conflictNode := child(insertNode, "on_conflict")
+			repoScope.resolveList(c, insert.OnConflict.Columns, child(conflictNode, "columns"))
+			repoScope.resolveList(c, insert.OnConflict.UpdateColumns, child(conflictNode, "update_columns"))
+		}
+	}
+
+	for i, update := range repo.Update {
+		updateNode := item(child(node, "update"), i)
+		repoScope.resolveList(c, update.Fields, child(updateNode, "fields"))
+		repoScope.resolveConditions(c, update.WhereConditions, child(updateNode, "where_conditions"))
+	}
+
+	for i, del := range repo.Delete {
+		deleteNode := item(child(node, "delete"), i)
+		repoScope.resolveConditions(c, del.WhereConditions, child(deleteNode, "where_conditions"))
+	}
+}
+
+// checkFields checks that every field of the struct has a column, and that the column can be scanned into the field.
+func (c *checker) checkFields(s *structure.Structure, table *ddl.Table, sourceNode *yaml.Node) {
+	for _, field := range s.Fields {
+		column := table.Column(field.DBFlag)
+		if column == nil {
+			c.reportField(field, sourceNode, "column %s of field %s.%s is not found in table %s",
+				field.DBFlag, s.Name, field.Name, table.Name)
+			continue
+		}
+
+		if problem := compareTypes(c.dialect, field.Type, *column); problem != "" {
+			c.reportField(field, sourceNode, "field %s.%s of type %s %s", s.Name, field.Name, field.Type, problem)
+		}
+	}
+}
+
+// scope is the tables that the columns of a query can refer to, by their names and aliases.
+type scope struct {
+	source *ddl.Table
+	tables map[string]*ddl.Table
+	joined []*ddl.Table
+}
+
+func newScope(source *ddl.Table) *scope {
+	s := &scope{source: source, tables: make(map[string]*ddl.Table)}
+	s.tables[strings.ToLower(source.Name)] = source
+	s.tables[strings.ToLower(unqualified(source.Name))] = source
+	return s
+}
+
+func (s *scope) join(join build.JoinField, table *ddl.Table) {
+	s.tables[strings.ToLower(join.Table)] = table
+	if join.As != "" {
+		s.tables[strings.ToLower(join.As)] = table
+	}
+	s.joined = append(s.joined, table)
+}
+
+// resolve reports the column if it is not found. Columns can be qualified by a table name or alias, and the
+// columns without qualifier are searched in the source table and then in the joined tables.
+func (s *scope) resolve(c *checker, column string, node *yaml.Node) {
+	if column == "" || column == "*" {
+		return
+	}
+
+	if index := strings.LastIndex(column, "."); index != -1 {
+		qualifier, name := column[:index], column[index+1:]
+		table, ok := s.tables[strings.ToLower(qualifier)]
+		if !ok {
+			c.report(node, "table %s of column %s is not the source table or a joined table", qualifier, column)
+			return
+		}
+		if name != "*" && table.Column(name) == nil {
+			c.report(node, "column %s is not found in table %s", name, table.Name)
+		}
+		return
+	}
+
+	if s.source.Column(column) != nil {
+		return
+	}
+	for _, table := range s.joined {
+		if table.Column(column) != nil {
+			return
+		}
+	}
+	c.report(node, "column %s is not found in table %s", column, s.source.Name)
+}
+
+func (s *scope) resolveList(c *checker, columns []string, node *yaml.Node) {
+	for i, column := range columns {
+		s.resolve(c, column, itemOrNode(node, i))
+	}
+}
+
+func (s *scope) resolveConditions(c *checker, conditions []build.WhereCondition, node *yaml.Node) {
+	for i, condition := range conditions {
+		s.resolve(c, condition.Column, child(item(node, i), "column"))
+	}
+}
+
+func unqualified(name string) string {
+	if index := strings.LastIndex(name, "."); index != -1 {
+		return name[index+1:]
+	}
+	return name
+}
+
+// child returns the value of a key of a mapping node, or nil if the node does not have it.
+func child(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// item returns an item of a sequence node, or nil if the node does not have it.
+func item(node *yaml.Node, index int) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+		return nil
+	}
+	return resolveAlias(node.Content[index])
+}
+
+// itemOrNode returns an item of a sequence node, or the node itself for the lists that are written as strings.
+func itemOrNode(node *yaml.Node, index int) *yaml.Node {
+	if itemNode := item(node, index); itemNode != nil {
+		return itemNode
+	}
+	return node
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// relativePath returns a path relative to the working directory, as the paths of the struct fields are reported.
+func relativePath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	relative, err := filepath.Rel(wd, path)
+	if err != nil {
+		return path
+	}
+	return relative
+}
@@ -0,0 +1,109 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/ddl"
+)
+
+type valueKind int
+
+const (
+	unknownKind valueKind = iota
+	boolKind
+	intKind
+	floatKind
+	decimalKind
+	stringKind
+	bytesKind
+	timeKind
+)
+
+var goKinds = map[string]valueKind{
+	"bool":            boolKind,
+	"int":             intKind,
+	"int8":            intKind,
+	"int16":           intKind,
+	"int32":           intKind,
+	"int64":           intKind,
+	"uint":            intKind,
+	"uint8":           intKind,
+	"uint16":          intKind,
+	"uint32":          intKind,
+	"uint64":          intKind,
+	"byte":            intKind,
+	"rune":            intKind,
+	"float32":         floatKind,
+	"float64":         floatKind,
+	"string":          stringKind,
+	"[]byte":          bytesKind,
+	"[]uint8":         bytesKind,
+	"time.Time":       timeKind,
+	"sql.NullBool":    boolKind,
+	"sql.NullByte":    intKind,
+	"sql.NullInt16":   intKind,
+	"sql.NullInt32":   intKind,
+	"sql.NullInt64":   intKind,
+	"sql.NullFloat64": floatKind,
+	"sql.NullString":  stringKind,
+	"sql.NullTime":    timeKind,
+}
+
+// scannable are the kinds of the columns that can be scanned into the kinds of the fields. Strings and byte
+// slices are left out, since database/sql scans every value into them.
+var scannable = map[valueKind][]valueKind{
+	boolKind:  {boolKind, intKind},
+	intKind:   {boolKind, intKind},
+	floatKind: {intKind, floatKind, decimalKind},
+	timeKind:  {timeKind},
+}
+
+// decimalTypes are the SQL types that are scanned as strings, but hold numbers
+var decimalTypes = []string{"decimal", "numeric", "money", "smallmoney", "number"}
+
+// compareTypes returns the problem of scanning a column into a field of the Go type, or an empty string. Named
+// types that are not known are skipped, since they may implement sql.Scanner.
+func compareTypes(dialect build.DialectType, fieldType string, column ddl.Column) string {
+	fieldKind, fieldNullable := kindOf(fieldType)
+	if fieldKind == unknownKind {
+		return ""
+	}
+
+	columnType := ddl.GoType(dialect, column)
+	columnKind, _ := kindOf(columnType)
+	lowerType := strings.ToLower(column.Type)
+	for _, decimalType := range decimalTypes {
+		if strings.HasPrefix(lowerType, decimalType) {
+			columnKind = decimalKind
+		}
+	}
+
+	if kinds, ok := scannable[fieldKind]; ok && !hasKind(kinds, columnKind) {
+		return fmt.Sprintf("can not scan column %s of type %s", column.Name, column.Type)
+	}
+	if !column.NotNull && !fieldNullable {
+		return fmt.Sprintf("can not scan NULL of column %s, use a pointer or a sql.Null type", column.Name)
+	}
+
+	return ""
+}
+
+// kindOf returns the kind of a Go type and whether it can hold NULL.
+func kindOf(goType string) (valueKind, bool) {
+	nullable := strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "sql.Null")
+	goType = strings.TrimPrefix(goType, "*")
+
+	kind := goKinds[goType]
+	return kind, nullable || kind == bytesKind
+}
+
+func hasKind(kinds []valueKind, kind valueKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// FileName is the name of the project configuration, which is looked up from the working directory upward.
+const FileName = ".crafting-table.yaml"
+
+// Config is the project configuration. It sets the defaults of every command, and the values of manifests and
+// flags take precedence over it.
+type Config struct {
+	Dialect     build.DialectType     `yaml:"dialect"`
+	PackageName string                `yaml:"package_name"`
+	DBLibrary   string                `yaml:"db_library"`
+	TableNaming structure.TableNaming `yaml:"table_naming"`
+	// Test is nil if the test generation is not set
+	Test *bool `yaml:"test"`
+	// OutputSuffix is the suffix of the names of the files that crafting-table names, i.e. the files of the query
+	// builder and the repositories of `import ddl`
+	OutputSuffix string `yaml:"output_suffix"`
+	// Templates is the directory of the templates that override the templates of the repositories and the query
+	// builders, relative to the configuration file
+	Templates string `yaml:"templates"`
+	// Generators are the generators of the manifest sections that crafting-table does not have
+	Generators []Generator `yaml:"generators"`
+
+	// Path is the path of the configuration file, or empty if there is none
+	Path string `yaml:"-"`
+}
+
+// Generator is a generator of a manifest section, which is either a Go plugin or an executable. Relative paths are
+// relative to the configuration file.
+type Generator struct {
+	// Plugin is the path of a Go plugin, whose generator has its section
+	Plugin string `yaml:"plugin"`
+	// Section is the section of the executable, and Exec and Args are its command. Exec is looked up in PATH if it is
+	// not a path.
+	Section string   `yaml:"section"`
+	Exec    string   `yaml:"exec"`
+	Args    []string `yaml:"args"`
+}
+
+// Find returns the configuration of the first directory from dir upward that has a configuration file. An empty
+// configuration is returned if there is none.
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return Load(path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return &Config{}, nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a configuration file. Unknown keys and values that are not supported are errors.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{Path: path}
+	d := yaml.NewDecoder(bytes.NewReader(content))
+	d.KnownFields(true)
+	if err := d.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	switch c.Dialect {
+	case "", build.MySQL, build.Postgres, build.SQLite3, build.SQLServer:
+	default:
+		return fmt.Errorf("dialect %q is not supported", c.Dialect)
+	}
+
+	if c.TableNaming != "" {
+		supported := false
+		var namings []string
+		for _, naming := range structure.TableNamings {
+			supported = supported || naming == c.TableNaming
+			namings = append(namings, string(naming))
+		}
+		if !supported {
+			return fmt.Errorf("table_naming %q is not one of %s", c.TableNaming, strings.Join(namings, ", "))
+		}
+	}
+
+	for i, g := range c.Generators {
+		switch {
+		case g.Plugin != "" && g.Exec != "":
+			return fmt.Errorf("generators[%d]: plugin and exec can not be set together", i)
+		case g.Plugin != "" && g.Section != "":
+			return fmt.Errorf("generators[%d]: section is the section of the plugin, and it can only be set for exec", i)
+		case g.Plugin == "" && g.Exec == "":
+			return fmt.Errorf("generators[%d]: plugin or exec is required", i)
+		case g.Exec != "" && g.Section == "":
+			return fmt.Errorf("generators[%d]: section is required for exec", i)
+		}
+	}
+
+	if c.OutputSuffix != "" && !strings.HasSuffix(c.OutputSuffix, ".go") {
+		return fmt.Errorf("output_suffix %q does not end with .go", c.OutputSuffix)
+	}
+
+	return nil
+}
+
+// Defaults returns the defaults of the repositories of manifests.
+func (c *Config) Defaults() craftingtable.Defaults {
+	return craftingtable.Defaults{
+		Dialect:     craftingtable.Dialect(c.Dialect),
+		PackageName: c.PackageName,
+		DBLibrary:   c.DBLibrary,
+		TableNaming: craftingtable.TableNaming(c.TableNaming),
+		Test:        c.Test,
+	}
+}
+
+// TemplatesDir returns the directory of the templates of the configuration, or empty if it does not set one.
+func (c *Config) TemplatesDir() string {
+	return c.resolve(c.Templates)
+}
+
+// RegisterGenerators registers the generators of the configuration.
+func (c *Config) RegisterGenerators() error {
+	for _, g := range c.Generators {
+		var sectionGenerator generator.Generator
+		if g.Plugin != "" {
+			var err error
+			if sectionGenerator, err = generator.Open(c.resolve(g.Plugin)); err != nil {
+				return fmt.Errorf("%s: %w", c.Path, err)
+			}
+		} else {
+			name := g.Exec
+			if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+				name = c.resolve(name)
+			}
+			sectionGenerator = generator.Exec(g.Section, name, g.Args...)
+		}
+
+		if err := generator.Register(sectionGenerator); err != nil {
+			return fmt.Errorf("%s: %w", c.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// resolve returns a path of the configuration, which is relative to the configuration file if it is not absolute.
+func (c *Config) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) || c.Path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.Path), path)
+}
+
+// DialectOr returns the dialect of the configuration, or the fallback if it does not set one.
+func (c *Config) DialectOr(fallback craftingtable.Dialect) craftingtable.Dialect {
+	if c.Dialect == "" {
+		return fallback
+	}
+	return craftingtable.Dialect(c.Dialect)
+}
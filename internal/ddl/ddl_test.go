@@ -0,0 +1,253 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect build.DialectType
+		schema  string
+		tables  []*Table
+		err     string
+	}{
+		{
+			name:    "postgres",
+			dialect: build.Postgres,
+			schema: `
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    email varchar(255) NOT NULL UNIQUE,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);`,
+			tables: []*Table{{
+				Name: "users",
+				Columns: []Column{
+					{Name: "id", Type: "bigserial", NotNull: true, PrimaryKey: true, AutoIncrement: true, Line: 3},
+					{Name: "email", Type: "varchar(255)", NotNull: true, Unique: true, Line: 4},
+					{Name: "created_at", Type: "timestamp with time zone", NotNull: true, Default: true, Line: 5},
+				},
+				PrimaryKey: []string{"id"},
+				UniqueKeys: [][]string{{"email"}},
+				Line:       2,
+			}},
+		},
+		{
+			name:    "mysql",
+			dialect: build.MySQL,
+			schema: "CREATE TABLE `orders` (\n" +
+				"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+				"  `user_id` bigint NOT NULL,\n" +
+				"  PRIMARY KEY (`id`)\n" +
+				") ENGINE=InnoDB;",
+			tables: []*Table{{
+				Name: "orders",
+				Columns: []Column{
+					{Name: "id", Type: "int unsigned", NotNull: true, PrimaryKey: true, AutoIncrement: true, Line: 2},
+					{Name: "user_id", Type: "bigint", NotNull: true, Line: 3},
+				},
+				PrimaryKey: []string{"id"},
+				Line:       1,
+			}},
+		},
+		{
+			name:    "keys of other statements",
+			dialect: build.Postgres,
+			schema: `
+CREATE TABLE user_roles (user_id bigint NOT NULL, role text NOT NULL);
+ALTER TABLE user_roles ADD CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role);
+CREATE UNIQUE INDEX user_roles_role ON user_roles (role);
+CREATE INDEX user_roles_user_id ON user_roles (user_id);
+CREATE UNIQUE INDEX other_name ON other (name);
+`,
+			tables: []*Table{{
+				Name: "user_roles",
+				Columns: []Column{
+					{Name: "user_id", Type: "bigint", NotNull: true, PrimaryKey: true, Line: 2},
+					{Name: "role", Type: "text", NotNull: true, PrimaryKey: true, Line: 2},
+				},
+				PrimaryKey: []string{"user_id", "role"},
+				UniqueKeys: [][]string{{"role"}},
+				Line:       2,
+			}},
+		},
+		{
+			name:    "integer primary key of sqlite",
+			dialect: build.SQLite3,
+			schema:  `CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);`,
+			tables: []*Table{{
+				Name: "notes",
+				Columns: []Column{
+					{Name: "id", Type: "INTEGER", NotNull: true, PrimaryKey: true, AutoIncrement: true, Line: 1},
+					{Name: "body", Type: "TEXT", Line: 1},
+				},
+				PrimaryKey: []string{"id"},
+				Line:       1,
+			}},
+		},
+		{
+			name:    "table declared twice",
+			dialect: build.Postgres,
+			schema:  "CREATE TABLE users (id int);\nCREATE TABLE users (id int);",
+			err:     "line 2: table users is declared more than once",
+		},
+		{
+			name:    "primary key column not found",
+			dialect: build.Postgres,
+			schema:  "CREATE TABLE users (\n  id int,\n  PRIMARY KEY (uid)\n);",
+			err:     "line 1: primary key column uid not found in table users",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tables, err := Parse(test.schema, test.dialect)
+			if test.err != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.tables, tables)
+		})
+	}
+}
+
+func TestGoType(t *testing.T) {
+	tests := []struct {
+		dialect build.DialectType
+		column  Column
+		goType  string
+	}{
+		{build.Postgres, Column{Type: "bigint", NotNull: true}, "int64"},
+		{build.Postgres, Column{Type: "bigint"}, "sql.NullInt64"},
+		{build.Postgres, Column{Type: "varchar(255)"}, "sql.NullString"},
+		{build.Postgres, Column{Type: "numeric(10, 2)", NotNull: true}, "string"},
+		{build.Postgres, Column{Type: "bytea"}, "[]byte"},
+		{build.Postgres, Column{Type: "timestamp with time zone", NotNull: true}, "time.Time"},
+		{build.MySQL, Column{Type: "tinyint(1)", NotNull: true}, "bool"},
+		{build.MySQL, Column{Type: "int unsigned", NotNull: true}, "uint32"},
+		{build.SQLite3, Column{Type: "INTEGER", NotNull: true}, "int64"},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.dialect)+" "+test.column.Type, func(t *testing.T) {
+			assert.Equal(t, test.goType, GoType(test.dialect, test.column))
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name   string
+		goName string
+	}{
+		{"id", "ID"},
+		{"uuid", "UUID"},
+		{"order_id", "OrderID"},
+		{"orderId", "OrderID"},
+		{"api_url", "APIURL"},
+		{"created_at", "CreatedAt"},
+		{"address2", "Address2"},
+		{"ident", "Ident"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.goName, goName(test.name))
+		})
+	}
+}
+
+func TestBuildModel(t *testing.T) {
+	tables, err := Parse(`
+CREATE TABLE order_items (
+    id bigserial PRIMARY KEY,
+    order_id bigint NOT NULL,
+    note text,
+    created_at timestamp NOT NULL
+);`, build.Postgres)
+	require.NoError(t, err)
+
+	model, err := BuildModel(tables[0], build.Postgres, "models")
+	require.NoError(t, err)
+	assert.Equal(t, `package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OrderItem is a row of the order_items table.
+type OrderItem struct {
+	ID        int64          `+"`db:\"id\"`"+`
+	OrderID   int64          `+"`db:\"order_id\"`"+`
+	Note      sql.NullString `+"`db:\"note\"`"+`
+	CreatedAt time.Time      `+"`db:\"created_at\"`"+`
+}
+`, model)
+}
+
+func TestBuildModelFieldNames(t *testing.T) {
+	tables, err := Parse("CREATE TABLE users (order_id int, orderId int);", build.Postgres)
+	require.NoError(t, err)
+
+	_, err = BuildModel(tables[0], build.Postgres, "models")
+	require.Error(t, err)
+	assert.Equal(t, "line 1: columns order_id and orderId of table users have the same field name OrderID", err.Error())
+}
+
+func TestBuildManifest(t *testing.T) {
+	tables, err := Parse(`
+CREATE TABLE users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL UNIQUE,
+    name text
+);`, build.Postgres)
+	require.NoError(t, err)
+
+	manifest, err := BuildManifest(tables, ManifestOptions{
+		Dialect:           build.Postgres,
+		ModelDir:          "models",
+		RepositoryDir:     "repository",
+		RepositoryPackage: "repository",
+		RepositorySuffix:  "_ct_gen.go",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `repositories:
+  - source: models/user.go
+    destination: repository/user_ct_gen.go
+    package_name: repository
+    struct_name: User
+    table_name: users
+    dialect: postgres
+    select:
+      - type: get
+        where_conditions:
+          - column: id
+            operator: equal
+      - type: get
+        where_conditions:
+          - column: email
+            operator: equal
+    insert:
+      - fields: [email, name]
+        with_object: true
+    update:
+      - fields: [email, name]
+        with_object: true
+        where_conditions:
+          - column: id
+            operator: equal
+    delete:
+      - where_conditions:
+          - column: id
+            operator: equal
+`, manifest)
+}
@@ -0,0 +1,287 @@
+package ddl
+
+import (
+	"fmt"
+	"go/format"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// StructName returns the name of the struct of a table, e.g. UserRole for public.user_roles.
+func StructName(table *Table) string {
+	name := table.Name
+	if index := strings.LastIndex(name, "."); index != -1 {
+		name = name[index+1:]
+	}
+
+	return goName(pluralize.NewClient().Singular(name))
+}
+
+// commonInitialisms are the words that Go names spell in upper case, as golint lists them
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// goName returns the Go name of a table or a column, with the common initialisms in upper case, e.g. OrderID for
+// order_id.
+func goName(name string) string {
+	words := strings.Split(strcase.ToSnake(name), "_")
+	for i, word := range words {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			words[i] = upper
+		} else {
+			words[i] = strcase.ToCamel(word)
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// FileName returns the name of the Go file of a table in both the model and the repository packages.
+func FileName(table *Table) string {
+	return strcase.ToSnake(StructName(table)) + ".go"
+}
+
+// repositoryFileName returns the name of the repository file of a table, which has the suffix instead of .go if it is
+// set.
+func repositoryFileName(table *Table, suffix string) string {
+	if suffix == "" {
+		return FileName(table)
+	}
+	return strings.TrimSuffix(FileName(table), ".go") + suffix
+}
+
+type modelField struct {
+	Name   string
+	Type   string
+	Column string
+}
+
+// BuildModel builds the Go file of the struct of a table with db tags.
+func BuildModel(table *Table, dialect build.DialectType, packageName string) (string, error) {
+	var fields []modelField
+	names := make(map[string]string)
+	imports := make(map[string]bool)
+	for _, column := range table.Columns {
+		name := goName(column.Name)
+		if other, ok := names[name]; ok {
+			return "", fmt.Errorf("line %d: columns %s and %s of table %s have the same field name %s",
+				column.Line, other, column.Name, table.Name, name)
+		}
+		names[name] = column.Name
+
+		goType := GoType(dialect, column)
+		if strings.HasPrefix(goType, "sql.") {
+			imports["database/sql"] = true
+		}
+		if strings.HasSuffix(goType, "time.Time") {
+			imports["time"] = true
+		}
+
+		fields = append(fields, modelField{
+			Name:   name,
+			Type:   goType,
+			Column: column.Name,
+		})
+	}
+
+	var importList []string
+	for _, importPath := range []string{"database/sql", "time"} {
+		if imports[importPath] {
+			importList = append(importList, importPath)
+		}
+	}
+
+	modelData := struct {
+		PackageName string
+		StructName  string
+		TableName   string
+		Imports     []string
+		Fields      []modelField
+	}{
+		PackageName: packageName,
+		StructName:  StructName(table),
+		TableName:   table.Name,
+		Imports:     importList,
+		Fields:      fields,
+	}
+
+	var builder strings.Builder
+	if err := modelTemplate.Execute(&builder, modelData); err != nil {
+		return "", err
+	}
+
+	source, err := format.Source([]byte(builder.String()))
+	if err != nil {
+		return "", fmt.Errorf("formatting model of table %s: %w", table.Name, err)
+	}
+
+	return string(source), nil
+}
+
+// ManifestOptions are the paths and the package of the repositories in the starter manifest.
+type ManifestOptions struct {
+	Dialect           build.DialectType
+	ModelDir          string
+	RepositoryDir     string
+	RepositoryPackage string
+	// RepositorySuffix replaces the .go extension of the file names of the repositories, e.g. _ct_gen.go
+	RepositorySuffix string
+}
+
+type manifestRepository struct {
+	Source       string
+	Destination  string
+	PackageName  string
+	StructName   string
+	TableName    string
+	Dialect      build.DialectType
+	GetKeys      [][]string
+	InsertFields []string
+	UpdateFields []string
+	PrimaryKey   []string
+}
+
+// BuildManifest builds a starter manifest with a repository for every table. Repositories get the rows by their
+// primary keys and unique keys, insert and update the columns that are not generated by the database, and
+// update and delete the rows by their primary keys.
+func BuildManifest(tables []*Table, options ManifestOptions) (string, error) {
+	var repositories []manifestRepository
+	for _, table := range tables {
+		repository := manifestRepository{
+			Source:      path.Join(options.ModelDir, FileName(table)),
+			Destination: path.Join(options.RepositoryDir, repositoryFileName(table, options.RepositorySuffix)),
+			PackageName: options.RepositoryPackage,
+			StructName:  StructName(table),
+			TableName:   table.Name,
+			Dialect:     options.Dialect,
+			PrimaryKey:  table.PrimaryKey,
+		}
+
+		seenKeys := make(map[string]bool)
+		for _, key := range append([][]string{table.PrimaryKey}, table.UniqueKeys...) {
+			keyName := strings.ToLower(strings.Join(key, ","))
+			if len(key) == 0 || seenKeys[keyName] {
+				continue
+			}
+			seenKeys[keyName] = true
+			repository.GetKeys = append(repository.GetKeys, key)
+		}
+
+		for _, column := range table.Columns {
+			if column.AutoIncrement || column.Generated {
+				continue
+			}
+			repository.InsertFields = append(repository.InsertFields, column.Name)
+			if !column.PrimaryKey {
+				repository.UpdateFields = append(repository.UpdateFields, column.Name)
+			}
+		}
+
+		repositories = append(repositories, repository)
+	}
+
+	var builder strings.Builder
+	if err := manifestTemplate.Execute(&builder, repositories); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+var manifestFuncMap = template.FuncMap{
+	// yaml quotes the names that are not plain strings in YAML
+	"yaml": func(value interface{}) (string, error) {
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	},
+	"list": func(values []string) (string, error) {
+		out, err := yaml.Marshal(values)
+		if err != nil {
+			return "", err
+		}
+
+		items := strings.Split(strings.TrimSpace(string(out)), "\n")
+		for i, item := range items {
+			items[i] = strings.TrimPrefix(item, "- ")
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	},
+}
+
+// model is the Go file of a table
+var modelTemplate *template.Template = template.Must(template.New("model").Parse(`
+package {{.PackageName}}
+
+{{ if .Imports }}import (
+{{ range .Imports }}	"{{ . }}"
+{{ end }})
+{{ end }}
+
+// {{.StructName}} is a row of the {{.TableName}} table.
+type {{.StructName}} struct {
+{{- range .Fields }}
+	{{.Name}} {{.Type}} ` + "`" + `db:"{{.Column}}"` + "`" + `
+{{- end }}
+}
+`))
+
+// manifest is the starter manifest of the tables
+var manifestTemplate *template.Template = template.Must(template.New("manifest").Funcs(manifestFuncMap).Parse(
+	`repositories:
+{{- range . }}
+  - source: {{ yaml .Source }}
+    destination: {{ yaml .Destination }}
+    package_name: {{ yaml .PackageName }}
+    struct_name: {{ .StructName }}
+    table_name: {{ yaml .TableName }}
+    dialect: {{ .Dialect }}
+    {{- if .GetKeys }}
+    select:
+      {{- range .GetKeys }}
+      - type: get
+        where_conditions:
+          {{- range . }}
+          - column: {{ yaml . }}
+            operator: equal
+          {{- end }}
+      {{- end }}
+    {{- end }}
+    {{- if .InsertFields }}
+    insert:
+      - fields: {{ list .InsertFields }}
+        with_object: true
+    {{- end }}
+    {{- if and .PrimaryKey .UpdateFields }}
+    update:
+      - fields: {{ list .UpdateFields }}
+        with_object: true
+        where_conditions:
+          {{- range .PrimaryKey }}
+          - column: {{ yaml . }}
+            operator: equal
+          {{- end }}
+    {{- end }}
+    {{- if .PrimaryKey }}
+    delete:
+      - where_conditions:
+          {{- range .PrimaryKey }}
+          - column: {{ yaml . }}
+            operator: equal
+          {{- end }}
+    {{- end }}
+{{- end }}
+`))
@@ -0,0 +1,123 @@
+package ddl
+
+import (
+	"fmt"
+	"unicode"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+type tokenKind int
+
+const (
+	identifierToken tokenKind = iota + 1
+	quotedIdentifierToken
+	stringToken
+	numberToken
+	symbolToken
+)
+
+type token struct {
+	kind tokenKind
+	// text is the text of the token, without the quotes of quoted identifiers
+	text string
+	line int
+}
+
+// closingQuotes are the quotes of identifiers by their opening quotes
+var closingQuotes = map[rune]rune{
+	'"': '"',
+	'`': '`',
+	'[': ']',
+}
+
+// tokenize splits a schema into tokens and drops its comments.
+func tokenize(src string, dialect build.DialectType) ([]token, error) {
+	runes := []rune(src)
+	line := 1
+
+	var tokens []token
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-',
+			r == '#' && dialect == build.MySQL:
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := line
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("line %d: comment is not closed", start)
+			}
+			i += 2
+		case r == '\'':
+			start := line
+			j := i + 1
+			for ; j < len(runes); j++ {
+				if runes[j] == '\n' {
+					line++
+				}
+				if runes[j] == '\\' && dialect == build.MySQL {
+					j++
+					continue
+				}
+				if runes[j] == '\'' {
+					// a quote is escaped by another quote
+					if j+1 < len(runes) && runes[j+1] == '\'' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("line %d: string is not closed", start)
+			}
+			tokens = append(tokens, token{kind: stringToken, text: string(runes[i : j+1]), line: start})
+			i = j + 1
+		case closingQuotes[r] != 0 && !(r == '[' && dialect != build.SQLServer && dialect != build.SQLite3):
+			closing := closingQuotes[r]
+			j := i + 1
+			for j < len(runes) && runes[j] != closing {
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("line %d: quoted name is not closed", line)
+			}
+			tokens = append(tokens, token{kind: quotedIdentifierToken, text: string(runes[i+1 : j]), line: line})
+			i = j + 1
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '$') {
+				j++
+			}
+			tokens = append(tokens, token{kind: identifierToken, text: string(runes[i:j]), line: line})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: numberToken, text: string(runes[i:j]), line: line})
+			i = j
+		default:
+			tokens = append(tokens, token{kind: symbolToken, text: string(r), line: line})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
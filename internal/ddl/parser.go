@@ -0,0 +1,611 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+type Column struct {
+	Name string
+	// Type is the SQL type of the column as it is declared, e.g. varchar(255) or timestamp with time zone
+	Type          string
+	NotNull       bool
+	PrimaryKey    bool
+	Unique        bool
+	AutoIncrement bool
+	// Generated columns are computed by the database, so they are not inserted or updated
+	Generated bool
+	Default   bool
+	// Line is the line of the column in the schema file
+	Line int
+}
+
+type Table struct {
+	Name       string
+	Columns    []Column
+	PrimaryKey []string
+	UniqueKeys [][]string
+	// Line is the line of the CREATE TABLE statement in the schema file
+	Line int
+}
+
+// Column returns the column with the given name, or nil if the table does not have it.
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+
+	return nil
+}
+
+// Parse parses the CREATE TABLE statements of a schema, and the primary keys and unique indexes that are added
+// to the tables by CREATE UNIQUE INDEX and ALTER TABLE statements. Other statements are skipped.
+func Parse(src string, dialect build.DialectType) ([]*Table, error) {
+	tokens, err := tokenize(src, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tables: make(map[string]*Table)}
+	for _, statement := range splitStatements(tokens) {
+		if err := p.parseStatement(statement); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, table := range p.order {
+		// rowversion columns of SQL Server are set by the database
+		if dialect == build.SQLServer {
+			for i := range table.Columns {
+				switch strings.ToLower(table.Columns[i].Type) {
+				case "rowversion", "timestamp":
+					table.Columns[i].Generated = true
+				}
+			}
+		}
+
+		// an INTEGER PRIMARY KEY column is an alias of the rowid in SQLite
+		if dialect == build.SQLite3 && len(table.PrimaryKey) == 1 {
+			if column := table.Column(table.PrimaryKey[0]); column != nil && strings.EqualFold(column.Type, "integer") {
+				column.AutoIncrement = true
+			}
+		}
+
+		for _, name := range table.PrimaryKey {
+			column := table.Column(name)
+			if column == nil {
+				return nil, fmt.Errorf("line %d: primary key column %s not found in table %s", table.Line, name, table.Name)
+			}
+			column.PrimaryKey = true
+			column.NotNull = true
+		}
+
+		for _, key := range table.UniqueKeys {
+			for _, name := range key {
+				if table.Column(name) == nil {
+					return nil, fmt.Errorf("line %d: unique key column %s not found in table %s", table.Line, name, table.Name)
+				}
+			}
+		}
+	}
+
+	return p.order, nil
+}
+
+type parser struct {
+	tables map[string]*Table
+	// order is the tables in order of declaration
+	order []*Table
+}
+
+func (p *parser) parseStatement(statement []token) error {
+	s := &stream{tokens: statement}
+
+	switch {
+	case s.keywords("CREATE"):
+		s.keywords("OR", "REPLACE")
+		for s.keyword("TEMPORARY", "TEMP", "UNLOGGED", "GLOBAL", "LOCAL") {
+		}
+
+		if s.keywords("TABLE") {
+			return p.parseCreateTable(s)
+		}
+
+		unique := s.keywords("UNIQUE")
+		s.keyword("CLUSTERED", "NONCLUSTERED")
+		if s.keywords("INDEX") {
+			return p.parseCreateIndex(s, unique)
+		}
+	case s.keywords("ALTER", "TABLE"):
+		return p.parseAlterTable(s)
+	}
+
+	return nil
+}
+
+func (p *parser) parseCreateTable(s *stream) error {
+	s.keywords("IF", "NOT", "EXISTS")
+
+	line := s.line()
+	name, err := s.name()
+	if err != nil {
+		return err
+	}
+
+	// CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE do not declare columns
+	if !s.symbol("(") {
+		return nil
+	}
+
+	table := &Table{Name: name, Line: line}
+	body, err := s.group()
+	if err != nil {
+		return err
+	}
+
+	for _, definition := range splitList(body) {
+		if len(definition) == 0 {
+			continue
+		}
+
+		if err := parseDefinition(table, &stream{tokens: definition}); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := p.tables[strings.ToLower(name)]; ok {
+		return fmt.Errorf("line %d: table %s is declared more than once", line, name)
+	}
+	p.tables[strings.ToLower(name)] = table
+	p.order = append(p.order, table)
+
+	return nil
+}
+
+func (p *parser) parseCreateIndex(s *stream, unique bool) error {
+	if !unique {
+		return nil
+	}
+
+	s.keyword("CONCURRENTLY")
+	s.keywords("IF", "NOT", "EXISTS")
+	if !s.keywords("ON") {
+		if _, err := s.name(); err != nil {
+			return err
+		}
+		if !s.keywords("ON") {
+			return s.errorf("expected ON in CREATE INDEX")
+		}
+	}
+	s.keywords("ONLY")
+
+	tableName, err := s.name()
+	if err != nil {
+		return err
+	}
+	if s.keywords("USING") {
+		s.next()
+	}
+
+	columns, err := s.columnList()
+	if err != nil {
+		return err
+	}
+
+	// indexes of tables that are not created in the schema are skipped, like the other statements
+	if table, ok := p.tables[strings.ToLower(tableName)]; ok {
+		table.UniqueKeys = append(table.UniqueKeys, columns)
+	}
+
+	return nil
+}
+
+func (p *parser) parseAlterTable(s *stream) error {
+	s.keywords("IF", "EXISTS")
+	s.keywords("ONLY")
+
+	tableName, err := s.name()
+	if err != nil {
+		return err
+	}
+
+	table, ok := p.tables[strings.ToLower(tableName)]
+	if !ok {
+		return nil
+	}
+
+	for _, action := range splitList(s.rest()) {
+		actionStream := &stream{tokens: action}
+		if actionStream.keywords("ADD") && actionStream.peekKeyword("CONSTRAINT", "PRIMARY", "UNIQUE") {
+			if err := parseDefinition(table, actionStream); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDefinition parses a column or a table constraint of a table.
+func parseDefinition(table *Table, s *stream) error {
+	if s.keywords("CONSTRAINT") {
+		s.next()
+	}
+
+	switch {
+	case s.keywords("PRIMARY", "KEY"):
+		s.keyword("CLUSTERED", "NONCLUSTERED")
+		columns, err := s.columnList()
+		if err != nil {
+			return err
+		}
+		if len(table.PrimaryKey) > 0 {
+			return s.errorf("table %s has more than one primary key", table.Name)
+		}
+		table.PrimaryKey = columns
+
+		return nil
+	case s.keywords("UNIQUE"):
+		s.keyword("KEY", "INDEX")
+		s.keyword("CLUSTERED", "NONCLUSTERED")
+		// the name of the key in MySQL
+		if !s.peekSymbol("(") {
+			s.next()
+		}
+		columns, err := s.columnList()
+		if err != nil {
+			return err
+		}
+		table.UniqueKeys = append(table.UniqueKeys, columns)
+
+		return nil
+	case s.peekKeyword("KEY", "INDEX", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL", "EXCLUDE", "LIKE", "PERIOD"):
+		return nil
+	}
+
+	return parseColumn(table, s)
+}
+
+// columnConstraints are the keywords that end the type of a column
+var columnConstraints = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "UNIQUE": true, "DEFAULT": true, "CONSTRAINT": true,
+	"REFERENCES": true, "CHECK": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true, "IDENTITY": true,
+	"GENERATED": true, "COLLATE": true, "COMMENT": true, "ON": true, "CHARSET": true, "AS": true,
+	"INVISIBLE": true, "VISIBLE": true, "STORED": true, "VIRTUAL": true,
+}
+
+func parseColumn(table *Table, s *stream) error {
+	line := s.line()
+	name, err := s.identifier()
+	if err != nil {
+		return err
+	}
+
+	column := Column{Name: name, Line: line}
+
+	var typeTokens []token
+	for !s.done() {
+		t := s.peek()
+		if t.kind == identifierToken && columnConstraints[strings.ToUpper(t.text)] {
+			break
+		}
+		// CHARACTER is a type in PostgreSQL, but CHARACTER SET is a column option in MySQL
+		if s.peekKeywords("CHARACTER", "SET") {
+			break
+		}
+		typeTokens = append(typeTokens, t)
+		s.next()
+		if t.text == "(" {
+			group, err := s.group()
+			if err != nil {
+				return err
+			}
+			typeTokens = append(append(typeTokens, group...), token{kind: symbolToken, text: ")"})
+		}
+	}
+	column.Type = joinType(typeTokens)
+
+	for !s.done() {
+		switch {
+		case s.keywords("NOT", "NULL"):
+			column.NotNull = true
+		case s.keywords("PRIMARY", "KEY"):
+			column.PrimaryKey = true
+			if len(table.PrimaryKey) > 0 {
+				return s.errorf("table %s has more than one primary key", table.Name)
+			}
+			table.PrimaryKey = []string{column.Name}
+		case s.keywords("UNIQUE"):
+			s.keyword("KEY")
+			column.Unique = true
+			table.UniqueKeys = append(table.UniqueKeys, []string{column.Name})
+		case s.keyword("AUTO_INCREMENT", "AUTOINCREMENT", "IDENTITY"):
+			column.AutoIncrement = true
+		case s.keywords("DEFAULT"):
+			column.Default = true
+			if s.next().text == "(" {
+				if _, err := s.group(); err != nil {
+					return err
+				}
+			}
+		case s.keywords("GENERATED"):
+			s.keyword("ALWAYS")
+			s.keywords("BY", "DEFAULT")
+			s.keywords("ON", "NULL")
+			s.keywords("AS")
+			if s.keywords("IDENTITY") {
+				column.AutoIncrement = true
+			} else {
+				column.Generated = true
+			}
+		case s.keywords("AS"):
+			// computed columns of SQL Server and generated columns of MySQL without GENERATED ALWAYS
+			column.Generated = true
+		default:
+			t := s.next()
+			if t.text == "(" {
+				if _, err := s.group(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if strings.Contains(strings.ToLower(column.Type), "serial") {
+		column.AutoIncrement = true
+	}
+
+	table.Columns = append(table.Columns, column)
+
+	return nil
+}
+
+// joinType joins the tokens of a type, e.g. numeric(10, 2), double precision or integer[].
+func joinType(tokens []token) string {
+	var builder strings.Builder
+	for i, t := range tokens {
+		if i > 0 && t.kind == identifierToken && tokens[i-1].text != "(" {
+			builder.WriteString(" ")
+		}
+		if t.text == "," {
+			builder.WriteString(", ")
+			continue
+		}
+		builder.WriteString(t.text)
+	}
+
+	return builder.String()
+}
+
+// splitStatements splits the tokens by semicolons and by the GO batch separators of SQL Server, that are on their
+// own lines.
+func splitStatements(tokens []token) [][]token {
+	var statements [][]token
+	var statement []token
+	for i, t := range tokens {
+		if t.text == ";" || isBatchSeparator(tokens, i) {
+			if len(statement) > 0 {
+				statements = append(statements, statement)
+			}
+			statement = nil
+			continue
+		}
+		statement = append(statement, t)
+	}
+	if len(statement) > 0 {
+		statements = append(statements, statement)
+	}
+
+	return statements
+}
+
+func isBatchSeparator(tokens []token, i int) bool {
+	if tokens[i].kind != identifierToken || !strings.EqualFold(tokens[i].text, "GO") {
+		return false
+	}
+
+	return (i == 0 || tokens[i-1].line < tokens[i].line) && (i == len(tokens)-1 || tokens[i+1].line > tokens[i].line)
+}
+
+// splitList splits the tokens by the commas that are not in parentheses.
+func splitList(tokens []token) [][]token {
+	var items [][]token
+	var item []token
+	depth := 0
+	for _, t := range tokens {
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				items = append(items, item)
+				item = nil
+				continue
+			}
+		}
+		item = append(item, t)
+	}
+
+	return append(items, item)
+}
+
+// stream reads the tokens of a statement.
+type stream struct {
+	tokens []token
+	index  int
+}
+
+func (s *stream) done() bool {
+	return s.index >= len(s.tokens)
+}
+
+func (s *stream) peek() token {
+	if s.done() {
+		return token{}
+	}
+	return s.tokens[s.index]
+}
+
+func (s *stream) next() token {
+	t := s.peek()
+	if !s.done() {
+		s.index++
+	}
+	return t
+}
+
+func (s *stream) rest() []token {
+	rest := s.tokens[s.index:]
+	s.index = len(s.tokens)
+	return rest
+}
+
+func (s *stream) line() int {
+	if s.done() {
+		if len(s.tokens) == 0 {
+			return 0
+		}
+		return s.tokens[len(s.tokens)-1].line
+	}
+	return s.peek().line
+}
+
+func (s *stream) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", s.line(), fmt.Sprintf(format, args...))
+}
+
+// peekKeyword reports whether the next token is one of the keywords.
+func (s *stream) peekKeyword(keywords ...string) bool {
+	t := s.peek()
+	if t.kind != identifierToken {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyword skips the next token if it is one of the keywords.
+func (s *stream) keyword(keywords ...string) bool {
+	if s.peekKeyword(keywords...) {
+		s.index++
+		return true
+	}
+	return false
+}
+
+// peekKeywords reports whether the next tokens are the keywords in order.
+func (s *stream) peekKeywords(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if s.index+i >= len(s.tokens) {
+			return false
+		}
+		t := s.tokens[s.index+i]
+		if t.kind != identifierToken || !strings.EqualFold(t.text, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// keywords skips the next tokens if they are the keywords in order.
+func (s *stream) keywords(keywords ...string) bool {
+	if s.peekKeywords(keywords...) {
+		s.index += len(keywords)
+		return true
+	}
+	return false
+}
+
+func (s *stream) peekSymbol(symbol string) bool {
+	t := s.peek()
+	return t.kind == symbolToken && t.text == symbol
+}
+
+func (s *stream) symbol(symbol string) bool {
+	if s.peekSymbol(symbol) {
+		s.index++
+		return true
+	}
+	return false
+}
+
+func (s *stream) identifier() (string, error) {
+	t := s.peek()
+	if t.kind != identifierToken && t.kind != quotedIdentifierToken {
+		return "", s.errorf("expected a name, found %q", t.text)
+	}
+	s.index++
+	return t.text, nil
+}
+
+// name reads a name that may be qualified by a schema, e.g. public.users.
+func (s *stream) name() (string, error) {
+	name, err := s.identifier()
+	if err != nil {
+		return "", err
+	}
+	for s.symbol(".") {
+		part, err := s.identifier()
+		if err != nil {
+			return "", err
+		}
+		name += "." + part
+	}
+	return name, nil
+}
+
+// group reads the tokens until the parenthesis that closes the opened one.
+func (s *stream) group() ([]token, error) {
+	start := s.line()
+	var tokens []token
+	depth := 1
+	for !s.done() {
+		t := s.next()
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return tokens, nil
+			}
+		}
+		tokens = append(tokens, t)
+	}
+
+	return nil, fmt.Errorf("line %d: parenthesis is not closed", start)
+}
+
+// columnList reads a parenthesized list of columns, skipping their lengths and orders, e.g. (name(10) DESC, id).
+func (s *stream) columnList() ([]string, error) {
+	if !s.symbol("(") {
+		return nil, s.errorf("expected ( before the columns")
+	}
+
+	group, err := s.group()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, item := range splitList(group) {
+		itemStream := &stream{tokens: item}
+		column, err := itemStream.identifier()
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
@@ -0,0 +1,209 @@
+package ddl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// goTypes are the Go types of the SQL types that have the same meaning in every dialect
+var goTypes = map[string]string{
+	"bool":    "bool",
+	"boolean": "bool",
+
+	"smallint":    "int16",
+	"int2":        "int16",
+	"smallserial": "int16",
+	"serial2":     "int16",
+	"int":         "int32",
+	"integer":     "int32",
+	"int4":        "int32",
+	"mediumint":   "int32",
+	"serial":      "int32",
+	"serial4":     "int32",
+	"bigint":      "int64",
+	"int8":        "int64",
+	"bigserial":   "int64",
+	"serial8":     "int64",
+
+	"real":             "float32",
+	"float4":           "float32",
+	"double":           "float64",
+	"double precision": "float64",
+	"float8":           "float64",
+	"float":            "float64",
+
+	// decimals are scanned as strings, so they do not lose precision
+	"decimal":    "string",
+	"numeric":    "string",
+	"dec":        "string",
+	"money":      "string",
+	"smallmoney": "string",
+
+	"char":              "string",
+	"character":         "string",
+	"varchar":           "string",
+	"character varying": "string",
+	"nchar":             "string",
+	"nvarchar":          "string",
+	"text":              "string",
+	"tinytext":          "string",
+	"mediumtext":        "string",
+	"longtext":          "string",
+	"ntext":             "string",
+	"citext":            "string",
+	"enum":              "string",
+	"set":               "string",
+	"uuid":              "string",
+	"json":              "string",
+	"jsonb":             "string",
+	"xml":               "string",
+	"inet":              "string",
+	"cidr":              "string",
+	"macaddr":           "string",
+	"interval":          "string",
+	"time":              "string",
+	"sysname":           "string",
+
+	"bytea":            "[]byte",
+	"blob":             "[]byte",
+	"tinyblob":         "[]byte",
+	"mediumblob":       "[]byte",
+	"longblob":         "[]byte",
+	"binary":           "[]byte",
+	"varbinary":        "[]byte",
+	"image":            "[]byte",
+	"rowversion":       "[]byte",
+	"uniqueidentifier": "[]byte",
+
+	"date":                        "time.Time",
+	"datetime":                    "time.Time",
+	"datetime2":                   "time.Time",
+	"smalldatetime":               "time.Time",
+	"datetimeoffset":              "time.Time",
+	"timestamp":                   "time.Time",
+	"timestamptz":                 "time.Time",
+	"timestamp with time zone":    "time.Time",
+	"timestamp without time zone": "time.Time",
+}
+
+// nullTypes are the wrappers of the Go types for nullable columns
+var nullTypes = map[string]string{
+	"bool":      "sql.NullBool",
+	"int8":      "sql.NullInt16",
+	"uint8":     "sql.NullInt16",
+	"int16":     "sql.NullInt16",
+	"uint16":    "sql.NullInt32",
+	"int32":     "sql.NullInt32",
+	"uint32":    "sql.NullInt64",
+	"int64":     "sql.NullInt64",
+	"uint64":    "sql.NullInt64",
+	"float32":   "sql.NullFloat64",
+	"float64":   "sql.NullFloat64",
+	"string":    "sql.NullString",
+	"time.Time": "sql.NullTime",
+}
+
+// unsignedTypes are the Go types of the unsigned integers of MySQL
+var unsignedTypes = map[string]string{
+	"tinyint":   "uint8",
+	"smallint":  "uint16",
+	"mediumint": "uint32",
+	"int":       "uint32",
+	"integer":   "uint32",
+	"bigint":    "uint64",
+}
+
+var typeArguments = regexp.MustCompile(`\s*\(.*?\)`)
+
+// GoType returns the Go type of a column. Nullable columns get the wrappers of database/sql, except for byte
+// slices that are nil for NULL. Types that are not known are scanned as strings.
+func GoType(dialect build.DialectType, column Column) string {
+	goType := baseGoType(dialect, column.Type)
+
+	if column.NotNull {
+		return goType
+	}
+	if nullType, ok := nullTypes[goType]; ok {
+		return nullType
+	}
+	return goType
+}
+
+func baseGoType(dialect build.DialectType, sqlType string) string {
+	lowerType := strings.ToLower(strings.TrimSpace(sqlType))
+
+	// arrays of PostgreSQL
+	if strings.HasSuffix(lowerType, "]") || strings.HasSuffix(lowerType, " array") {
+		return "string"
+	}
+
+	unsigned := false
+	for _, modifier := range []string{" unsigned", " zerofill", " signed"} {
+		if strings.Contains(lowerType, modifier) {
+			unsigned = unsigned || modifier == " unsigned"
+			lowerType = strings.ReplaceAll(lowerType, modifier, "")
+		}
+	}
+
+	baseType := strings.TrimSpace(typeArguments.ReplaceAllString(lowerType, ""))
+
+	switch dialect {
+	case build.MySQL:
+		switch {
+		case lowerType == "tinyint(1)" || lowerType == "bit(1)":
+			return "bool"
+		case unsigned && unsignedTypes[baseType] != "":
+			return unsignedTypes[baseType]
+		case baseType == "tinyint":
+			return "int8"
+		case baseType == "year":
+			return "int16"
+		case baseType == "float":
+			return "float32"
+		case baseType == "bit":
+			return "[]byte"
+		}
+	case build.SQLServer:
+		switch baseType {
+		case "bit":
+			return "bool"
+		case "tinyint":
+			return "uint8"
+		case "timestamp":
+			return "[]byte"
+		}
+	case build.SQLite3:
+		if goType, ok := goTypes[baseType]; ok {
+			// integers of SQLite are 64 bits
+			if strings.HasPrefix(goType, "int") {
+				return "int64"
+			}
+			return goType
+		}
+		return sqliteAffinity(baseType)
+	}
+
+	if goType, ok := goTypes[baseType]; ok {
+		return goType
+	}
+
+	return "string"
+}
+
+// sqliteAffinity returns the Go type of the type affinity of a column, that SQLite finds by the name of its type.
+func sqliteAffinity(baseType string) string {
+	switch {
+	case strings.Contains(baseType, "int"):
+		return "int64"
+	case strings.Contains(baseType, "char"), strings.Contains(baseType, "clob"), strings.Contains(baseType, "text"):
+		return "string"
+	case baseType == "", strings.Contains(baseType, "blob"):
+		return "[]byte"
+	case strings.Contains(baseType, "real"), strings.Contains(baseType, "floa"), strings.Contains(baseType, "doub"):
+		return "float64"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,129 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// SnapshotFile is the file of the migrations directory that keeps the schema of the last migration.
+const SnapshotFile = "ct_snapshot.json"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+
+// Migration is a pair of golang-migrate files, e.g. 000002_schema.up.sql and 000002_schema.down.sql.
+type Migration struct {
+	UpFile   string
+	DownFile string
+	Up       []string
+	Down     []string
+}
+
+// LoadSnapshot loads the schema of the last migration of a directory. The schema is empty before the first
+// migration.
+func LoadSnapshot(dir string) (*Schema, error) {
+	content, err := os.ReadFile(filepath.Join(dir, SnapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Schema{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, SnapshotFile), err)
+	}
+
+	return &schema, nil
+}
+
+// Plan returns the migration from the snapshot of a directory to a schema, or nil if the schema is not changed.
+func Plan(dir, name string, schema *Schema) (*Migration, error) {
+	snapshot, err := LoadSnapshot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	up, err := Diff(snapshot, schema)
+	if err != nil {
+		return nil, err
+	}
+	if len(up) == 0 {
+		return nil, nil
+	}
+
+	down, err := Diff(schema, &Schema{Dialect: schema.Dialect, Tables: snapshot.Tables})
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := nextVersion(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migration{
+		UpFile:   filepath.Join(dir, version+"_"+name+".up.sql"),
+		DownFile: filepath.Join(dir, version+"_"+name+".down.sql"),
+		Up:       up,
+		Down:     down,
+	}, nil
+}
+
+// Write writes the files of the migration and saves the schema as the snapshot of the directory.
+func (m *Migration) Write(dir string, schema *Schema) error {
+	files, err := m.Files(dir, schema)
+	if err != nil {
+		return err
+	}
+
+	return build.WriteFiles(files)
+}
+
+// Files returns the files of the migration and the snapshot of the schema, without writing them.
+func (m *Migration) Files(dir string, schema *Schema) ([]build.File, error) {
+	snapshot, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []build.File{
+		{Path: m.UpFile, Content: strings.Join(m.Up, "\n\n") + "\n"},
+		{Path: m.DownFile, Content: strings.Join(m.Down, "\n\n") + "\n"},
+		{Path: filepath.Join(dir, SnapshotFile), Content: string(snapshot) + "\n"},
+	}, nil
+}
+
+// nextVersion returns the version after the last migration of a directory, with the width of its versions.
+func nextVersion(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	last, width := uint64(0), 6
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		if version >= last {
+			last, width = version, len(matches[1])
+		}
+	}
+
+	return fmt.Sprintf("%0*d", width, last+1), nil
+}
@@ -0,0 +1,315 @@
+package migrate
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/querybuilder"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+)
+
+type Column struct {
+	Name string `json:"name"`
+	// Type is the SQL type of the column in the dialect of the schema
+	Type          string `json:"type"`
+	Nullable      bool   `json:"nullable,omitempty"`
+	Default       string `json:"default,omitempty"`
+	AutoIncrement bool   `json:"auto_increment,omitempty"`
+}
+
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+type Table struct {
+	Name       string   `json:"name"`
+	Columns    []Column `json:"columns"`
+	PrimaryKey []string `json:"primary_key,omitempty"`
+	Indexes    []Index  `json:"indexes,omitempty"`
+}
+
+// Schema is the tables of the models, that is saved as the snapshot of the last migration.
+type Schema struct {
+	Dialect build.DialectType `json:"dialect"`
+	Tables  []Table           `json:"tables"`
+}
+
+func (s *Schema) table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+func (t *Table) column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *Table) index(name string) *Index {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// LoadSchema builds the schema of the structs that are annotated with `ct: model` in a package, or in a file of
+// a package. Tables are named by the table naming strategy, like the query builder names them, and columns are named
+// by their db tags or by the snake case of the fields. The files of the generated suffix, e.g. the query builders,
+// are skipped.
+func LoadSchema(path string, dialect build.DialectType, tableNaming structure.TableNaming, generatedSuffix string) (*Schema, error) {
+	pkg, err := structure.LoadPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{Dialect: dialect}
+	for _, file := range structure.SourceFiles(pkg, path, generatedSuffix) {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE || !strings.HasPrefix(genDecl.Doc.Text(), querybuilder.ModelAnnotation) {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				table, err := buildTable(pkg, dialect, tableNaming, typeSpec)
+				if err != nil {
+					return nil, err
+				}
+
+				if schema.table(table.Name) != nil {
+					return nil, fmt.Errorf("%s: table %s is declared more than once",
+						pkg.Fset.Position(typeSpec.Pos()), table.Name)
+				}
+				schema.Tables = append(schema.Tables, *table)
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// tableBuilder builds a table from the fields of a struct and of its embedded structs.
+type tableBuilder struct {
+	pkg     *packages.Package
+	dialect build.DialectType
+	table   *Table
+	// indexes are the columns of the indexes by their names, in order of declaration
+	indexes     map[string]*Index
+	indexOrder  []string
+	autoColumns int
+}
+
+func buildTable(pkg *packages.Package, dialect build.DialectType, tableNaming structure.TableNaming,
+	typeSpec *ast.TypeSpec) (*Table, error) {
+	position := pkg.Fset.Position(typeSpec.Pos())
+	structType, ok := pkg.TypesInfo.Defs[typeSpec.Name].Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s: model %s is not a struct", position, typeSpec.Name.Name)
+	}
+
+	b := tableBuilder{
+		pkg:     pkg,
+		dialect: dialect,
+		table:   &Table{Name: tableNaming.TableName(typeSpec.Name.Name)},
+		indexes: make(map[string]*Index),
+	}
+	if err := b.addFields(structType); err != nil {
+		return nil, err
+	}
+
+	if len(b.table.Columns) == 0 {
+		return nil, fmt.Errorf("%s: model %s has no columns", position, typeSpec.Name.Name)
+	}
+	if b.autoColumns > 0 && (b.autoColumns > 1 || len(b.table.PrimaryKey) != 1 ||
+		!b.table.column(b.table.PrimaryKey[0]).AutoIncrement) {
+		return nil, fmt.Errorf("%s: auto_increment is only supported on the primary key column of model %s",
+			position, typeSpec.Name.Name)
+	}
+
+	for _, name := range b.indexOrder {
+		b.table.Indexes = append(b.table.Indexes, *b.indexes[name])
+	}
+
+	return b.table, nil
+}
+
+func (b *tableBuilder) addFields(structType *types.Struct) error {
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tags := reflect.StructTag(structType.Tag(i))
+		position := b.pkg.Fset.Position(field.Pos())
+
+		dbFlag, hasDBFlag := tags.Lookup("db")
+		if dbFlag == "-" {
+			continue
+		}
+
+		if field.Embedded() && !hasDBFlag {
+			fieldType := field.Type()
+			if pointer, ok := fieldType.(*types.Pointer); ok {
+				fieldType = pointer.Elem()
+			}
+			if embedded, ok := fieldType.Underlying().(*types.Struct); ok {
+				if err := b.addFields(embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.Exported() {
+			continue
+		}
+
+		options, err := parseOptions(tags.Get("ct"))
+		if err != nil {
+			return fmt.Errorf("%s: %s", position, err)
+		}
+
+		name := dbFlag
+		if name == "" {
+			name = strcase.ToSnake(field.Name())
+		}
+		if b.table.column(name) != nil {
+			return fmt.Errorf("%s: column %s is declared more than once", position, name)
+		}
+
+		column := Column{
+			Name:          name,
+			Default:       options.defaultValue,
+			AutoIncrement: options.autoIncrement,
+		}
+		if options.sqlType != "" {
+			column.Type = options.sqlType
+			column.Nullable = isNullable(field.Type())
+		} else {
+			column.Type, column.Nullable, err = sqlType(b.dialect, field.Type(), options.size, options.indexed())
+			if err != nil {
+				return fmt.Errorf("%s: field %s: %s", position, field.Name(), err)
+			}
+		}
+
+		if options.primaryKey {
+			column.Nullable = false
+			b.table.PrimaryKey = append(b.table.PrimaryKey, name)
+		}
+		if column.AutoIncrement {
+			b.autoColumns++
+		}
+
+		if err := b.addIndex(options.unique, true, name); err != nil {
+			return fmt.Errorf("%s: %s", position, err)
+		}
+		if err := b.addIndex(options.index, false, name); err != nil {
+			return fmt.Errorf("%s: %s", position, err)
+		}
+
+		b.table.Columns = append(b.table.Columns, column)
+	}
+
+	return nil
+}
+
+// addIndex adds the column to the named indexes. Indexes without names only have the column.
+func (b *tableBuilder) addIndex(names []string, unique bool, column string) error {
+	for _, name := range names {
+		if name == "" {
+			suffix := "idx"
+			if unique {
+				suffix = "key"
+			}
+			name = b.table.Name + "_" + column + "_" + suffix
+		}
+
+		index, ok := b.indexes[name]
+		if !ok {
+			index = &Index{Name: name, Unique: unique}
+			b.indexes[name] = index
+			b.indexOrder = append(b.indexOrder, name)
+		}
+		if index.Unique != unique {
+			return fmt.Errorf("index %s is both unique and not unique", name)
+		}
+		index.Columns = append(index.Columns, column)
+	}
+
+	return nil
+}
+
+// options are the options of the ct tag of a field, e.g. `ct:"pk,size=64,unique=users_email_key,default='new'"`.
+type options struct {
+	primaryKey    bool
+	autoIncrement bool
+	// unique and index are the names of the indexes of the column, that are empty for single column indexes
+	unique       []string
+	index        []string
+	defaultValue string
+	size         int
+	sqlType      string
+}
+
+func (o options) indexed() bool {
+	return o.primaryKey || len(o.unique) > 0 || len(o.index) > 0
+}
+
+func parseOptions(tag string) (options, error) {
+	var o options
+	if tag == "" {
+		return o, nil
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(option), "=")
+		switch key {
+		case "pk":
+			o.primaryKey = true
+		case "auto_increment":
+			o.autoIncrement = true
+		case "unique":
+			o.unique = append(o.unique, value)
+		case "index":
+			o.index = append(o.index, value)
+		case "default":
+			if !hasValue || value == "" {
+				return o, fmt.Errorf("default of ct tag needs a value")
+			}
+			o.defaultValue = value
+		case "size":
+			size, err := strconv.Atoi(value)
+			if err != nil || size <= 0 {
+				return o, fmt.Errorf("size of ct tag is not a positive number: %q", value)
+			}
+			o.size = size
+		case "type":
+			if !hasValue || value == "" {
+				return o, fmt.Errorf("type of ct tag needs a value")
+			}
+			o.sqlType = value
+		default:
+			return o, fmt.Errorf("option %q of ct tag is not supported", key)
+		}
+	}
+
+	return o, nil
+}
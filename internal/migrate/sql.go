@@ -0,0 +1,313 @@
+package migrate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// Diff returns the statements that migrate a database from the old schema to the new one. Renamed tables and
+// columns are dropped and added again, since they can not be told apart from the schemas.
+func Diff(oldSchema, newSchema *Schema) ([]string, error) {
+	dialect := newSchema.Dialect
+	if oldSchema.Dialect != "" && oldSchema.Dialect != dialect {
+		return nil, fmt.Errorf("dialect of the snapshot is %s, not %s", oldSchema.Dialect, dialect)
+	}
+
+	var statements []string
+	for i := range newSchema.Tables {
+		newTable := &newSchema.Tables[i]
+		oldTable := oldSchema.table(newTable.Name)
+		if oldTable == nil {
+			statements = append(statements, createTable(dialect, newTable)...)
+			continue
+		}
+
+		tableStatements, err := alterTable(dialect, oldTable, newTable)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, tableStatements...)
+	}
+
+	// tables are dropped in reverse order of declaration
+	for i := len(oldSchema.Tables) - 1; i >= 0; i-- {
+		if newSchema.table(oldSchema.Tables[i].Name) == nil {
+			statements = append(statements, fmt.Sprintf("DROP TABLE %s;", quote(dialect, oldSchema.Tables[i].Name)))
+		}
+	}
+
+	return statements, nil
+}
+
+func alterTable(dialect build.DialectType, oldTable, newTable *Table) ([]string, error) {
+	if strings.Join(oldTable.PrimaryKey, ",") != strings.Join(newTable.PrimaryKey, ",") {
+		return nil, fmt.Errorf("primary key of table %s is changed, which needs a migration by hand", newTable.Name)
+	}
+
+	// SQLite can not alter columns or add the columns that are not null without default, so the table is
+	// created again with the new columns
+	if dialect == build.SQLite3 && needsRebuild(oldTable, newTable) {
+		return rebuildTable(dialect, oldTable, newTable), nil
+	}
+
+	var statements []string
+	table := quote(dialect, newTable.Name)
+
+	for _, oldIndex := range oldTable.Indexes {
+		newIndex := newTable.index(oldIndex.Name)
+		if newIndex == nil || !sameIndex(oldIndex, *newIndex) {
+			statements = append(statements, dropIndex(dialect, newTable.Name, oldIndex))
+		}
+	}
+
+	for _, oldColumn := range oldTable.Columns {
+		if newTable.column(oldColumn.Name) != nil {
+			continue
+		}
+		if dialect == build.SQLServer && oldColumn.Default != "" {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;",
+				table, quote(dialect, defaultConstraint(newTable.Name, oldColumn.Name))))
+		}
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, quote(dialect, oldColumn.Name)))
+	}
+
+	for _, newColumn := range newTable.Columns {
+		oldColumn := oldTable.column(newColumn.Name)
+		if oldColumn == nil {
+			statements = append(statements, addColumn(dialect, newTable, newColumn))
+			continue
+		}
+		if *oldColumn == newColumn {
+			continue
+		}
+
+		columnStatements, err := alterColumn(dialect, newTable, *oldColumn, newColumn)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, columnStatements...)
+	}
+
+	for _, newIndex := range newTable.Indexes {
+		oldIndex := oldTable.index(newIndex.Name)
+		if oldIndex == nil || !sameIndex(*oldIndex, newIndex) {
+			statements = append(statements, createIndex(dialect, newTable.Name, newIndex))
+		}
+	}
+
+	return statements, nil
+}
+
+func alterColumn(dialect build.DialectType, t *Table, oldColumn, newColumn Column) ([]string, error) {
+	table := quote(dialect, t.Name)
+	column := quote(dialect, newColumn.Name)
+
+	switch dialect {
+	case build.MySQL:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, columnDefinition(dialect, t, newColumn))}, nil
+	case build.Postgres:
+		var statements []string
+		if oldColumn.Type != newColumn.Type {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, column, newColumn.Type))
+		}
+		if oldColumn.Nullable != newColumn.Nullable {
+			action := "SET NOT NULL"
+			if newColumn.Nullable {
+				action = "DROP NOT NULL"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, action))
+		}
+		if oldColumn.Default != newColumn.Default {
+			action := "DROP DEFAULT"
+			if newColumn.Default != "" {
+				action = "SET DEFAULT " + newColumn.Default
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, action))
+		}
+		if oldColumn.AutoIncrement != newColumn.AutoIncrement {
+			action := "DROP IDENTITY"
+			if newColumn.AutoIncrement {
+				action = "ADD GENERATED BY DEFAULT AS IDENTITY"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s;", table, column, action))
+		}
+		return statements, nil
+	case build.SQLServer:
+		if oldColumn.AutoIncrement != newColumn.AutoIncrement {
+			return nil, fmt.Errorf("identity of column %s of table %s is changed, which needs a migration by hand",
+				newColumn.Name, t.Name)
+		}
+
+		var statements []string
+		if oldColumn.Default != "" && oldColumn.Default != newColumn.Default {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;",
+				table, quote(dialect, defaultConstraint(t.Name, newColumn.Name))))
+		}
+		if oldColumn.Type != newColumn.Type || oldColumn.Nullable != newColumn.Nullable {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s;",
+				table, column, newColumn.Type, nullability(newColumn)))
+		}
+		if newColumn.Default != "" && oldColumn.Default != newColumn.Default {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s DEFAULT %s FOR %s;",
+				table, quote(dialect, defaultConstraint(t.Name, newColumn.Name)), newColumn.Default, column))
+		}
+		return statements, nil
+	}
+
+	return nil, fmt.Errorf("dialect %q is not supported", dialect)
+}
+
+func createTable(dialect build.DialectType, t *Table) []string {
+	definitions := make([]string, 0, len(t.Columns)+1)
+	for _, column := range t.Columns {
+		definitions = append(definitions, columnDefinition(dialect, t, column))
+	}
+	if len(t.PrimaryKey) > 0 && !inlinePrimaryKey(dialect, t) {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(dialect, t.PrimaryKey)))
+	}
+
+	statements := []string{fmt.Sprintf("CREATE TABLE %s (\n    %s\n);",
+		quote(dialect, t.Name), strings.Join(definitions, ",\n    "))}
+	for _, index := range t.Indexes {
+		statements = append(statements, createIndex(dialect, t.Name, index))
+	}
+
+	return statements
+}
+
+// rebuildTable creates the table again with the new columns and copies the rows of the common columns.
+func rebuildTable(dialect build.DialectType, oldTable, newTable *Table) []string {
+	temporary := *newTable
+	temporary.Name = newTable.Name + "_new"
+	temporary.Indexes = nil
+
+	var columns []string
+	for _, column := range newTable.Columns {
+		if oldTable.column(column.Name) != nil {
+			columns = append(columns, column.Name)
+		}
+	}
+
+	statements := createTable(dialect, &temporary)
+	statements = append(statements,
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s;", quote(dialect, temporary.Name),
+			quoteList(dialect, columns), quoteList(dialect, columns), quote(dialect, newTable.Name)),
+		fmt.Sprintf("DROP TABLE %s;", quote(dialect, newTable.Name)),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s;", quote(dialect, temporary.Name), quote(dialect, newTable.Name)),
+	)
+	for _, index := range newTable.Indexes {
+		statements = append(statements, createIndex(dialect, newTable.Name, index))
+	}
+
+	return statements
+}
+
+func needsRebuild(oldTable, newTable *Table) bool {
+	for _, newColumn := range newTable.Columns {
+		oldColumn := oldTable.column(newColumn.Name)
+		if oldColumn == nil && !newColumn.Nullable && newColumn.Default == "" {
+			return true
+		}
+		if oldColumn != nil && *oldColumn != newColumn {
+			return true
+		}
+	}
+	return false
+}
+
+func addColumn(dialect build.DialectType, t *Table, column Column) string {
+	if dialect == build.SQLServer {
+		return fmt.Sprintf("ALTER TABLE %s ADD %s;", quote(dialect, t.Name), columnDefinition(dialect, t, column))
+	}
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", quote(dialect, t.Name), columnDefinition(dialect, t, column))
+}
+
+func createIndex(dialect build.DialectType, table string, index Index) string {
+	unique := ""
+	if index.Unique {
+		unique = "UNIQUE "
+	}
+
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);",
+		unique, quote(dialect, index.Name), quote(dialect, table), quoteList(dialect, index.Columns))
+}
+
+func dropIndex(dialect build.DialectType, table string, index Index) string {
+	switch dialect {
+	case build.MySQL, build.SQLServer:
+		return fmt.Sprintf("DROP INDEX %s ON %s;", quote(dialect, index.Name), quote(dialect, table))
+	default:
+		return fmt.Sprintf("DROP INDEX %s;", quote(dialect, index.Name))
+	}
+}
+
+func columnDefinition(dialect build.DialectType, t *Table, column Column) string {
+	definition := []string{quote(dialect, column.Name), column.Type}
+
+	if column.AutoIncrement {
+		switch dialect {
+		case build.MySQL:
+			definition = append(definition, "NOT NULL AUTO_INCREMENT")
+		case build.Postgres:
+			definition = append(definition, "GENERATED BY DEFAULT AS IDENTITY")
+		case build.SQLite3:
+			definition = append(definition, "PRIMARY KEY AUTOINCREMENT")
+		case build.SQLServer:
+			definition = append(definition, "IDENTITY(1,1) NOT NULL")
+		}
+		return strings.Join(definition, " ")
+	}
+
+	definition = append(definition, nullability(column))
+	if column.Default != "" {
+		// defaults of SQL Server are named, so they can be dropped by later migrations
+		if dialect == build.SQLServer {
+			definition = append(definition, "CONSTRAINT", quote(dialect, defaultConstraint(t.Name, column.Name)))
+		}
+		definition = append(definition, "DEFAULT", column.Default)
+	}
+
+	return strings.Join(definition, " ")
+}
+
+// inlinePrimaryKey reports whether the primary key is declared by its column, which SQLite needs for
+// AUTOINCREMENT.
+func inlinePrimaryKey(dialect build.DialectType, t *Table) bool {
+	return dialect == build.SQLite3 && len(t.PrimaryKey) == 1 && t.column(t.PrimaryKey[0]).AutoIncrement
+}
+
+func nullability(column Column) string {
+	if column.Nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func defaultConstraint(table, column string) string {
+	return "DF_" + table + "_" + column
+}
+
+func sameIndex(index1, index2 Index) bool {
+	return index1.Unique == index2.Unique && strings.Join(index1.Columns, ",") == strings.Join(index2.Columns, ",")
+}
+
+func quote(dialect build.DialectType, name string) string {
+	switch dialect {
+	case build.MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case build.SQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+func quoteList(dialect build.DialectType, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(dialect, name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,182 @@
+package migrate
+
+import (
+	"fmt"
+	"go/types"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+type columnKind int
+
+const (
+	boolKind columnKind = iota + 1
+	smallIntKind
+	intKind
+	bigIntKind
+	floatKind
+	doubleKind
+	stringKind
+	bytesKind
+	timeKind
+	uuidKind
+	jsonKind
+)
+
+// namedKinds are the kinds of the types that are not mapped by their underlying types, with the nullable wrappers
+// of database/sql
+var namedKinds = map[string]columnKind{
+	"time.Time":                   timeKind,
+	"encoding/json.RawMessage":    jsonKind,
+	"github.com/google/uuid.UUID": uuidKind,
+	"database/sql.NullBool":       boolKind,
+	"database/sql.NullByte":       smallIntKind,
+	"database/sql.NullInt16":      smallIntKind,
+	"database/sql.NullInt32":      intKind,
+	"database/sql.NullInt64":      bigIntKind,
+	"database/sql.NullFloat64":    doubleKind,
+	"database/sql.NullString":     stringKind,
+	"database/sql.NullTime":       timeKind,
+}
+
+var basicKinds = map[types.BasicKind]columnKind{
+	types.Bool:    boolKind,
+	types.Int8:    smallIntKind,
+	types.Uint8:   smallIntKind,
+	types.Int16:   smallIntKind,
+	types.Uint16:  intKind,
+	types.Int32:   intKind,
+	types.Int:     bigIntKind,
+	types.Uint32:  bigIntKind,
+	types.Int64:   bigIntKind,
+	types.Uint:    bigIntKind,
+	types.Uint64:  bigIntKind,
+	types.Float32: floatKind,
+	types.Float64: doubleKind,
+	types.String:  stringKind,
+}
+
+// sqlTypes are the SQL types of the kinds by dialect
+var sqlTypes = map[build.DialectType]map[columnKind]string{
+	build.MySQL: {
+		boolKind:     "BOOLEAN",
+		smallIntKind: "SMALLINT",
+		intKind:      "INT",
+		bigIntKind:   "BIGINT",
+		floatKind:    "FLOAT",
+		doubleKind:   "DOUBLE",
+		stringKind:   "TEXT",
+		bytesKind:    "BLOB",
+		timeKind:     "DATETIME(6)",
+		uuidKind:     "CHAR(36)",
+		jsonKind:     "JSON",
+	},
+	build.Postgres: {
+		boolKind:     "BOOLEAN",
+		smallIntKind: "SMALLINT",
+		intKind:      "INTEGER",
+		bigIntKind:   "BIGINT",
+		floatKind:    "REAL",
+		doubleKind:   "DOUBLE PRECISION",
+		stringKind:   "TEXT",
+		bytesKind:    "BYTEA",
+		timeKind:     "TIMESTAMP WITH TIME ZONE",
+		uuidKind:     "UUID",
+		jsonKind:     "JSONB",
+	},
+	build.SQLite3: {
+		boolKind:     "BOOLEAN",
+		smallIntKind: "INTEGER",
+		intKind:      "INTEGER",
+		bigIntKind:   "INTEGER",
+		floatKind:    "REAL",
+		doubleKind:   "REAL",
+		stringKind:   "TEXT",
+		bytesKind:    "BLOB",
+		timeKind:     "DATETIME",
+		uuidKind:     "TEXT",
+		jsonKind:     "TEXT",
+	},
+	build.SQLServer: {
+		boolKind:     "BIT",
+		smallIntKind: "SMALLINT",
+		intKind:      "INT",
+		bigIntKind:   "BIGINT",
+		floatKind:    "REAL",
+		doubleKind:   "FLOAT",
+		stringKind:   "NVARCHAR(MAX)",
+		bytesKind:    "VARBINARY(MAX)",
+		timeKind:     "DATETIME2",
+		uuidKind:     "UNIQUEIDENTIFIER",
+		jsonKind:     "NVARCHAR(MAX)",
+	},
+}
+
+// indexedSize is the size of the indexed strings and byte slices without size in MySQL and SQL Server, that can
+// not index their unlimited types
+const indexedSize = 255
+
+// sqlType returns the SQL type of a Go type and whether it is nullable. Pointers and the nullable wrappers of
+// database/sql are nullable, and named types are mapped by their underlying types.
+func sqlType(dialect build.DialectType, goType types.Type, size int, indexed bool) (string, bool, error) {
+	nullable := isNullable(goType)
+	if pointer, ok := goType.(*types.Pointer); ok {
+		goType = pointer.Elem()
+	}
+
+	kind, err := kindOf(goType)
+	if err != nil {
+		return "", false, err
+	}
+
+	dialectTypes, ok := sqlTypes[dialect]
+	if !ok {
+		return "", false, fmt.Errorf("dialect %q is not supported", dialect)
+	}
+
+	if size == 0 && indexed && (dialect == build.MySQL || dialect == build.SQLServer) {
+		size = indexedSize
+	}
+	if size > 0 {
+		switch {
+		case kind == stringKind && dialect == build.SQLServer:
+			return fmt.Sprintf("NVARCHAR(%d)", size), nullable, nil
+		case kind == stringKind:
+			return fmt.Sprintf("VARCHAR(%d)", size), nullable, nil
+		case kind == bytesKind && (dialect == build.MySQL || dialect == build.SQLServer):
+			return fmt.Sprintf("VARBINARY(%d)", size), nullable, nil
+		}
+	}
+
+	return dialectTypes[kind], nullable, nil
+}
+
+func isNullable(goType types.Type) bool {
+	if _, ok := goType.(*types.Pointer); ok {
+		return true
+	}
+
+	named, ok := goType.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "database/sql"
+}
+
+func kindOf(goType types.Type) (columnKind, error) {
+	if named, ok := goType.(*types.Named); ok && named.Obj().Pkg() != nil {
+		if kind, ok := namedKinds[named.Obj().Pkg().Path()+"."+named.Obj().Name()]; ok {
+			return kind, nil
+		}
+	}
+
+	switch t := goType.Underlying().(type) {
+	case *types.Basic:
+		if kind, ok := basicKinds[t.Kind()]; ok {
+			return kind, nil
+		}
+	case *types.Slice:
+		if basic, ok := t.Elem().(*types.Basic); ok && basic.Kind() == types.Byte {
+			return bytesKind, nil
+		}
+	}
+
+	return 0, fmt.Errorf("type %s is not supported, set its SQL type with the type option of ct tag", goType)
+}
@@ -3,15 +3,18 @@ package querybuilder
 import (
 	"fmt"
 	"go/ast"
+	"go/types"
 	"strings"
 
-	"github.com/gertd/go-pluralize"
-	"github.com/iancoleman/strcase"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
 )
 
 const ModelAnnotation = "ct: model"
 
-type structField struct {
+// Field is a field of the struct of a query builder. IsComparable reports whether it is a number, which has the
+// range operators.
+type Field struct {
 	Name         string
 	Type         string
 	IsComparable bool
@@ -19,34 +22,35 @@ type structField struct {
 	Tag          string
 }
 
-func (s structField) String() string {
+func (s Field) String() string {
 	return s.Name
 }
 
-func isComparable(typeExpr ast.Expr) bool {
-	switch t := typeExpr.(type) {
-	case *ast.Ident:
-		if t.Obj == nil {
-			// it's a primitive go type
-			if t.Name == "int" || t.Name == "int8" || t.Name == "int16" || t.Name == "int32" || t.Name == "int64" ||
-				t.Name == "uint" || t.Name == "uint8" || t.Name == "uint16" || t.Name == "uint32" || t.Name == "uint64" ||
-				t.Name == "float32" || t.Name == "float64" {
-				return true
-			}
-			return false
+// isComparable reports whether the type is a number, so it can be compared with the range operators.
+func isComparable(fieldType types.Type) bool {
+	basic, ok := fieldType.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsInteger|types.IsFloat) != 0 && basic.Kind() != types.Uintptr
+}
+
+// resolveTypes resolves the types of the fields with the type information of the package, so types that are
+// declared in other files or packages are resolved too. Packages of the types are recorded in imports.
+func resolveTypes(pkg *types.Package, info *types.Info, structDecl *ast.GenDecl, imports map[string]string) []Field {
+	qualifier := func(other *types.Package) string {
+		if other == pkg {
+			return ""
 		}
+		imports[other.Path()] = other.Name()
+		return other.Name()
 	}
-	return false
-}
 
-func resolveTypes(structDecl *ast.GenDecl) []structField {
-	var fields []structField
+	var fields []Field
 	for _, field := range structDecl.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+		fieldType := info.TypeOf(field.Type)
 		for _, name := range field.Names {
-			sf := structField{
+			sf := Field{
 				Name:         name.Name,
-				Type:         fmt.Sprint(field.Type),
-				IsComparable: isComparable(field.Type),
+				Type:         types.TypeString(fieldType, qualifier),
+				IsComparable: isComparable(fieldType),
 				IsNullable:   false, // TODO: fix this
 			}
 			if field.Tag != nil {
@@ -58,23 +62,33 @@ func resolveTypes(structDecl *ast.GenDecl) []structField {
 	return fields
 }
 
-func Generate(dialect string, pkg string, structDecl *ast.GenDecl) string {
-	fields := resolveTypes(structDecl)
+// Generate returns the query builder of an annotated struct. Its table is named by the table naming strategy. If
+// tmpl is nil, the query builder is generated by DefaultTemplates.
+func Generate(dialect string, tableNaming structure.TableNaming, tmpl *templates.Set, pkg *types.Package,
+	info *types.Info, structDecl *ast.GenDecl) (string, error) {
+	if tmpl == nil {
+		tmpl = DefaultTemplates
+	}
+
+	imports := make(map[string]string)
+	fields := resolveTypes(pkg, info, structDecl, imports)
+	standardImports, otherImports := structure.ImportSpecs(imports)
 	typeName := structDecl.Specs[0].(*ast.TypeSpec).Name.String()
 	var buff strings.Builder
-	td := templateData{
+	td := TemplateData{
 		ModelName: typeName,
 		Fields:    fields,
-		Pkg:       pkg,
+		Pkg:       pkg.Name(),
+		Imports:   append(standardImports, otherImports...),
 		Dialect:   dialect,
-		TableName: strcase.ToSnake(pluralize.NewClient().Plural(typeName)),
+		TableName: tableNaming.TableName(typeName),
 	}
 
-	for _, t := range queryBuilderTemplates {
-		if err := t.Execute(&buff, td); err != nil {
-			panic(err)
+	for _, name := range Names {
+		if err := tmpl.Execute(&buff, name, td); err != nil {
+			return "", fmt.Errorf("error in generating query builder of %s: %w", typeName, err)
 		}
 	}
 
-	return buff.String()
+	return buff.String(), nil
 }
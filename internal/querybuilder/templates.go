@@ -1,63 +1,58 @@
 package querybuilder
 
 import (
-	"text/template"
-
-	"github.com/iancoleman/strcase"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
 )
 
-var funcMap = template.FuncMap{
-	"toSnakeCase": func(name string) string {
-		return strcase.ToSnake(name)
-	},
-	"ToLowerCamelCase": func(name string) string {
-		return strcase.ToLowerCamel(name)
-	},
-}
-
-var (
-	baseOutputFileTemplate        = template.Must(template.New("ct-base").Funcs(funcMap).Parse(baseOutputFile))
-	setsTemplate                  = template.Must(template.New("ct-sets").Funcs(funcMap).Parse(sets))
-	eqWhereTemplate               = template.Must(template.New("ct-eq-where").Funcs(funcMap).Parse(eqWhere))
-	scalarWhereTemplate           = template.Must(template.New("ct-scalar-where").Funcs(funcMap).Parse(scalarWhere))
-	limitOffsetTemplate           = template.Must(template.New("ct-limit-offset").Funcs(funcMap).Parse(limitOffset))
-	queryBuilderTemplate          = template.Must(template.New("ct-query-builder").Funcs(funcMap).Parse(queryBuilder))
-	selectQueryBuilderTemplate    = template.Must(template.New("ct-select-builder").Funcs(funcMap).Parse(selectQueryBuilder))
-	updateQueryBuilderTemplate    = template.Must(template.New("ct-update-builder").Funcs(funcMap).Parse(updateQueryBuilder))
-	deleteQueryBuilderTemplate    = template.Must(template.New("ct-delete-builder").Funcs(funcMap).Parse(deleteQueryBuilder))
-	fromRowsTemplate              = template.Must(template.New("ct-from-rows").Funcs(funcMap).Parse(fromRows))
-	toRowsTemplate                = template.Must(template.New("ct-to-rows").Funcs(funcMap).Parse(toRows))
-	finishersTemplate             = template.Must(template.New("ct-finishers").Funcs(funcMap).Parse(finishers))
-	placeholderGeneratorTemplate  = template.Must(template.New("ct-placeholder").Funcs(funcMap).Parse(placeholderGenerator))
-	schemaTemplate                = template.Must(template.New("ct-schema").Funcs(funcMap).Parse(schema))
-	queryBuilderInterfaceTemplate = template.Must(template.New("ct-interface").Funcs(funcMap).Parse(queryBuilderInterface))
-	orderByTemplate               = template.Must(template.New("ct-orderby").Funcs(funcMap).Parse(orderBy))
+// DefaultTemplates are the templates of query builders. Every template is executed with TemplateData, and the
+// query builder of a struct is the results of Names in order.
+var DefaultTemplates = templates.Must(
+	templates.Template{Name: "ct-base", Source: baseOutputFile},
+	templates.Template{Name: "ct-interface", Source: queryBuilderInterface},
+	templates.Template{Name: "ct-schema", Source: schema},
+	templates.Template{Name: "ct-orderby", Source: orderBy},
+	templates.Template{Name: "ct-query-builder", Source: queryBuilder},
+	templates.Template{Name: "ct-select-builder", Source: selectQueryBuilder},
+	templates.Template{Name: "ct-limit-offset", Source: limitOffset},
+	templates.Template{Name: "ct-update-builder", Source: updateQueryBuilder},
+	templates.Template{Name: "ct-delete-builder", Source: deleteQueryBuilder},
+	templates.Template{Name: "ct-eq-where", Source: eqWhere},
+	templates.Template{Name: "ct-scalar-where", Source: scalarWhere},
+	templates.Template{Name: "ct-sets", Source: sets},
+	templates.Template{Name: "ct-from-rows", Source: fromRows},
+	templates.Template{Name: "ct-to-rows", Source: toRows},
+	templates.Template{Name: "ct-placeholder", Source: placeholderGenerator},
+	templates.Template{Name: "ct-finishers", Source: finishers},
 )
 
-var queryBuilderTemplates = []*template.Template{
-	baseOutputFileTemplate,
-	queryBuilderInterfaceTemplate,
-	schemaTemplate,
-	orderByTemplate,
-	queryBuilderTemplate,
-	selectQueryBuilderTemplate,
-	limitOffsetTemplate,
-	updateQueryBuilderTemplate,
-	deleteQueryBuilderTemplate,
-	eqWhereTemplate,
-	scalarWhereTemplate,
-	setsTemplate,
-	fromRowsTemplate,
-	toRowsTemplate,
-	placeholderGeneratorTemplate,
-	finishersTemplate,
-}
-
-type templateData struct {
+// Names are the names of the templates of a query builder in the order of the file.
+var Names = []string{
+	"ct-base",
+	"ct-interface",
+	"ct-schema",
+	"ct-orderby",
+	"ct-query-builder",
+	"ct-select-builder",
+	"ct-limit-offset",
+	"ct-update-builder",
+	"ct-delete-builder",
+	"ct-eq-where",
+	"ct-scalar-where",
+	"ct-sets",
+	"ct-from-rows",
+	"ct-to-rows",
+	"ct-placeholder",
+	"ct-finishers",
+}
+
+// TemplateData is the data of the templates of a query builder.
+type TemplateData struct {
+	// Pkg is the name of the package of the struct, and Imports are the quoted import paths of its field types
 	Pkg       string
+	Imports   []string
 	ModelName string
 	TableName string
-	Fields    []structField
+	Fields    []Field
 	Dialect   string
 }
 
@@ -441,5 +436,7 @@ import (
     "fmt"
     "strings"
     "database/sql"
+{{ range .Imports }}
+    {{ . }}{{ end }}
 )
 `
@@ -0,0 +1,36 @@
+package structure
+
+import (
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+// TableNaming is the strategy that names the table of a struct that does not set its table name.
+type TableNaming string
+
+const (
+	// SnakeCase names the table of UserRole user_role, like manifests do by default.
+	SnakeCase TableNaming = "snake_case"
+	// PluralSnakeCase names the table of UserRole user_roles, like the query builder and migrations do by default.
+	PluralSnakeCase TableNaming = "plural_snake_case"
+)
+
+// TableNamings are the supported table naming strategies.
+var TableNamings = []TableNaming{SnakeCase, PluralSnakeCase}
+
+// TableName returns the table name of a struct.
+func (n TableNaming) TableName(structName string) string {
+	if n == PluralSnakeCase {
+		return strcase.ToSnake(pluralize.NewClient().Plural(structName))
+	}
+
+	return strcase.ToSnake(structName)
+}
+
+// Or returns the naming, or the fallback if the naming is not set.
+func (n TableNaming) Or(fallback TableNaming) TableNaming {
+	if n == "" {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,298 @@
+package structure
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loaded caches the packages of LoadPackage by their patterns, since a manifest usually binds many structs of one
+// package. A cached package is loaded again when the Go files of its directory change.
+var loaded = struct {
+	sync.Mutex
+	packages map[string]loadedPackage
+}{packages: map[string]loadedPackage{}}
+
+type loadedPackage struct {
+	pkg   *packages.Package
+	files string
+}
+
+// LoadPackage loads the package of an import path, a directory or a Go file with its syntax and types.
+// Type errors of the package are kept in the errors of the package, so stale generated files do not prevent
+// loading it.
+func LoadPackage(path string) (*packages.Package, error) {
+	pattern, file := path, ""
+	if info, err := os.Stat(path); err == nil {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern = absolutePath
+		if !info.IsDir() {
+			pattern, file = "file="+absolutePath, absolutePath
+		}
+	}
+
+	loaded.Lock()
+	defer loaded.Unlock()
+
+	if pkg := cachedPackage(pattern, file); pkg != nil {
+		return pkg, nil
+	}
+
+	config := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes |
+			packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+	}
+	pkgs, err := packages.Load(config, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected one package, found %d", path, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	for _, pkgError := range pkg.Errors {
+		if pkgError.Kind != packages.TypeError {
+			return nil, errors.New(pkgError.Error())
+		}
+	}
+	if len(pkg.Syntax) == 0 {
+		return nil, fmt.Errorf("%s: no Go files found", path)
+	}
+
+	loaded.packages[pattern] = loadedPackage{pkg: pkg, files: packageFiles(pkg)}
+
+	return pkg, nil
+}
+
+// cachedPackage returns the loaded package of a pattern, or of a Go file of a loaded package, if the Go files of the
+// package have not changed since it was loaded.
+func cachedPackage(pattern, file string) *packages.Package {
+	for cachedPattern, cached := range loaded.packages {
+		if cachedPattern != pattern && (file == "" || !containsString(cached.pkg.GoFiles, file)) {
+			continue
+		}
+
+		if cached.files == packageFiles(cached.pkg) {
+			return cached.pkg
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// packageFiles describes the Go files of the directory of a package by their names, sizes and modification times.
+func packageFiles(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return ""
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(pkg.GoFiles[0]))
+	if err != nil {
+		return ""
+	}
+
+	var files strings.Builder
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return ""
+		}
+
+		_, _ = fmt.Fprintf(&files, "%s %d %d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return files.String()
+}
+
+// SourceFiles returns the files of a loaded package that models are read from. The files of the generated suffix,
+// e.g. the query builders of the package, are skipped, and a Go file path only returns that file.
+func SourceFiles(pkg *packages.Package, path, generatedSuffix string) []*ast.File {
+	var pathInfo os.FileInfo
+	if strings.HasSuffix(path, ".go") {
+		pathInfo, _ = os.Stat(path)
+	}
+
+	var files []*ast.File
+	for _, file := range pkg.Syntax {
+		fileName := pkg.Fset.File(file.Pos()).Name()
+		if generatedSuffix != "" && strings.HasSuffix(fileName, generatedSuffix) {
+			continue
+		}
+
+		if pathInfo != nil {
+			if fileInfo, err := os.Stat(fileName); err != nil || !os.SameFile(pathInfo, fileInfo) {
+				continue
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files
+}
+
+// bindPackage binds a struct of a package, or of a Go file of a package. Types of the fields are resolved across the
+// files of the package and are qualified by their package names, including the types of the package itself.
+func bindPackage(src, structName string) (*Structure, error) {
+	pkg, err := LoadPackage(src)
+	if err != nil {
+		return nil, err
+	}
+
+	files := pkg.Syntax
+	if strings.HasSuffix(src, ".go") {
+		files = SourceFiles(pkg, src, "")
+	}
+
+	var typeSpec *ast.TypeSpec
+	for _, file := range files {
+		for _, spec := range structTypes(file) {
+			if structName == "" || spec.Name.Name == structName {
+				typeSpec = spec
+				break
+			}
+		}
+		if typeSpec != nil {
+			break
+		}
+	}
+	if typeSpec == nil {
+		if structName == "" {
+			return nil, fmt.Errorf("%s: no struct found", src)
+		}
+		return nil, fmt.Errorf("%s: struct %s not found", src, structName)
+	}
+
+	named, ok := pkg.TypesInfo.Defs[typeSpec.Name].Type().(*types.Named)
+	if !ok {
+		return nil, fmt.Errorf("%s: struct %s is not a named type", pkg.Fset.Position(typeSpec.Pos()), typeSpec.Name.Name)
+	}
+
+	structure := newStructure(pkg.Name, typeSpec.Name.Name)
+	structure.Imports[pkg.PkgPath] = pkg.Name
+
+	b := packageBinder{
+		pkg:       pkg,
+		structure: structure,
+		visited:   map[*types.TypeName]bool{named.Obj(): true},
+	}
+	if err := b.bindFields(named.Underlying().(*types.Struct)); err != nil {
+		return nil, err
+	}
+
+	return structure, nil
+}
+
+type packageBinder struct {
+	pkg       *packages.Package
+	structure *Structure
+	// visited is the set of structs that are being flattened, for finding recursive embedding
+	visited map[*types.TypeName]bool
+}
+
+func (b *packageBinder) bindFields(structType *types.Struct) error {
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		tags := reflect.StructTag(structType.Tag(i))
+		position := b.pkg.Fset.Position(field.Pos())
+
+		dbFlag, hasDBFlag := tags.Lookup("db")
+		if dbFlag == "-" {
+			continue
+		}
+
+		// sqlx maps the fields of embedded structs as the fields of the struct itself
+		if field.Embedded() && !hasDBFlag {
+			if err := b.bindEmbedded(field, position); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.Exported() {
+			continue
+		}
+
+		if !hasDBFlag {
+			return fmt.Errorf("%s: db tag not found for field %s", position, field.Name())
+		}
+
+		fieldType := types.TypeString(field.Type(), b.qualifier)
+		if strings.Contains(fieldType, "invalid type") {
+			return fmt.Errorf("%s: type of field %s is not valid: %s", position, field.Name(), b.typeError())
+		}
+
+		if err := b.structure.addField(position, field.Name(), fieldType, tags, dbFlag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *packageBinder) bindEmbedded(field *types.Var, position token.Position) error {
+	fieldType := field.Type()
+	if pointer, ok := fieldType.(*types.Pointer); ok {
+		fieldType = pointer.Elem()
+	}
+
+	named, ok := fieldType.(*types.Named)
+	if !ok {
+		return fmt.Errorf("%s: embedded field %s is not valid: %s", position, field.Name(), b.typeError())
+	}
+
+	structType, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s: embedded field %s is not a struct", position, field.Name())
+	}
+
+	if b.visited[named.Obj()] {
+		return fmt.Errorf("%s: struct %s is embedded in itself", position, named.Obj().Name())
+	}
+	b.visited[named.Obj()] = true
+	defer delete(b.visited, named.Obj())
+
+	return b.bindFields(structType)
+}
+
+// qualifier qualifies the types of other packages by their names and records them as imports.
+func (b *packageBinder) qualifier(pkg *types.Package) string {
+	b.structure.Imports[pkg.Path()] = pkg.Name()
+	return pkg.Name()
+}
+
+// typeError returns the first type error of the package, which is the reason of an invalid type.
+func (b *packageBinder) typeError() string {
+	if len(b.pkg.Errors) == 0 {
+		return "unknown type"
+	}
+	return b.pkg.Errors[0].Error()
+}
@@ -1,18 +1,22 @@
 package structure
 
 import (
-	"bufio"
-	"errors"
-	"os"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
-
-	"github.com/iancoleman/strcase"
 )
 
 type Field struct {
 	Name   string
 	Type   string
 	DBFlag string
+	Tags   reflect.StructTag
+	// Position is where the field is declared, e.g. to report the problems of its column
+	Position token.Position
 }
 
 type Structure struct {
@@ -23,91 +27,112 @@ type Structure struct {
 	FieldMapNameToType   map[string]string
 	FieldMapDBFlagToName map[string]string
 	FieldMapNameToDBFlag map[string]string
+	// Imports are the packages that the types of the fields refer to, by import path to package name
+	Imports map[string]string
 }
 
+// BindStruct binds the struct with the given name, or the first struct if the name is empty. The source is a Go
+// file, or a directory or an import path of a package, which is loaded with its types. The struct of a Go file is
+// one that the file declares, and its fields are resolved in the package of the file.
 func BindStruct(src, structName string) (*Structure, error) {
-	f, err := os.Open(src)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
-
-	scanner := bufio.NewScanner(f)
-
-	funcFound := false
-	structure := new(Structure)
+	return bindPackage(src, structName)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
+func newStructure(packageName, name string) *Structure {
+	return &Structure{
+		PackageName:          packageName,
+		TableName:            SnakeCase.TableName(name),
+		Name:                 name,
+		FieldMapNameToType:   make(map[string]string),
+		FieldMapDBFlagToName: make(map[string]string),
+		FieldMapNameToDBFlag: make(map[string]string),
+		Imports:              make(map[string]string),
+	}
+}
 
-		if strings.Contains(line, "package") {
-			structure.PackageName = strings.Split(line, " ")[1]
+// structTypes returns the struct types that are declared in the file by their names in order of declaration.
+func structTypes(file *ast.File) []*ast.TypeSpec {
+	var structs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
 			continue
 		}
 
-		if strings.Contains(line, "type") && strings.Contains(line, "struct") {
-			if structName != "" && !strings.Contains(line, structName) {
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
 				continue
 			}
-			funcFound = true
-			tmp := strings.Split(line, " ")
-			structure.Name = tmp[1]
-			structure.TableName = strcase.ToSnake(tmp[1])
-			continue
+			if _, ok := typeSpec.Type.(*ast.StructType); ok {
+				structs = append(structs, typeSpec)
+			}
 		}
+	}
 
-		if funcFound && string(line[0]) == "}" {
-			break
-		}
+	return structs
+}
 
-		if funcFound {
-			for strings.Contains(line, "  ") {
-				line = strings.Replace(line, "  ", " ", -1)
-			}
-			tmp := strings.Split(line, " ")
-			if len(tmp) < 3 {
-				return nil, errors.New("invalid structure")
-			}
+func (s *Structure) addField(position token.Position, name, fieldType string, tags reflect.StructTag, dbFlag string) error {
+	if dbFlag == "" {
+		return fmt.Errorf("%s: db tag is not valid for field %s", position, name)
+	}
+	if _, ok := s.FieldMapDBFlagToName[dbFlag]; ok {
+		return fmt.Errorf("%s: db tag %q is used by fields %s and %s",
+			position, dbFlag, s.FieldMapDBFlagToName[dbFlag], name)
+	}
+	if _, ok := s.FieldMapNameToType[name]; ok {
+		return fmt.Errorf("%s: field %s is declared more than once", position, name)
+	}
 
-			structField := Field{}
-			structField.Name = tmp[0]
-			structField.Type = tmp[1]
+	structField := Field{
+		Name:     name,
+		Type:     fieldType,
+		DBFlag:   dbFlag,
+		Tags:     tags,
+		Position: position,
+	}
+	s.Fields = append(s.Fields, structField)
 
-			if !strings.Contains(tmp[2], "db") {
-				return nil, errors.New("db tag not found for field " + structField.Name)
-			}
+	s.FieldMapDBFlagToName[structField.DBFlag] = structField.Name
+	s.FieldMapNameToDBFlag[structField.Name] = structField.DBFlag
+	s.FieldMapNameToType[structField.Name] = structField.Type
 
-			index := strings.Index(tmp[2], ":")
-			if index == -1 {
-				return nil, errors.New("db tag is not valid for filed " + structField.Name)
-			}
+	return nil
+}
 
-			structField.DBFlag = tmp[2][index+2 : len(tmp[2])-2]
-			structure.Fields = append(structure.Fields, structField)
+// ImportName guesses the package name of an import path, e.g. faker for github.com/bxcodec/faker/v3.
+func ImportName(path string) string {
+	elements := strings.Split(path, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elements[len(elements)-2]
+	}
 
-			if structure.FieldMapDBFlagToName == nil {
-				structure.FieldMapDBFlagToName = make(map[string]string)
-			}
-			if structure.FieldMapNameToDBFlag == nil {
-				structure.FieldMapNameToDBFlag = make(map[string]string)
-			}
-			if structure.FieldMapNameToType == nil {
-				structure.FieldMapNameToType = make(map[string]string)
-			}
+	return strings.TrimPrefix(strings.TrimPrefix(name, "go-"), "go.")
+}
 
-			structure.FieldMapDBFlagToName[structField.DBFlag] = structField.Name
-			structure.FieldMapNameToDBFlag[structField.Name] = structField.DBFlag
-			structure.FieldMapNameToType[structField.Name] = structField.Type
-		}
+// ImportSpecs returns the import specs of the standard library and of other packages, with the package name only
+// when the path does not imply it.
+func ImportSpecs(imports map[string]string) (standard []string, other []string) {
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	for _, path := range paths {
+		spec := strconv.Quote(path)
+		if name := imports[path]; ImportName(path) != name {
+			spec = name + " " + spec
+		}
+
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			standard = append(standard, spec)
+		}
 	}
 
-	return structure, nil
+	return standard, other
 }
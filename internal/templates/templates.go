@@ -0,0 +1,152 @@
+package templates
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gertd/go-pluralize"
+	"github.com/iancoleman/strcase"
+)
+
+// Extension is the extension of the files of a template directory. The name of a file without it is the name of the
+// template that it overrides, e.g. function.tmpl overrides the function template.
+const Extension = ".tmpl"
+
+// Funcs are the functions that every template can call.
+var Funcs = template.FuncMap{
+	"toSnakeCase":      strcase.ToSnake,
+	"ToLowerCamelCase": strcase.ToLowerCamel,
+	"toLowerCamelCase": strcase.ToLowerCamel,
+	"toCamelCase":      strcase.ToCamel,
+	"plural":           pluralize.NewClient().Plural,
+	"singular":         pluralize.NewClient().Singular,
+	"lower":            strings.ToLower,
+	"upper":            strings.ToUpper,
+	"join":             strings.Join,
+	"quote":            strconv.Quote,
+}
+
+// Template is a named template and its source.
+type Template struct {
+	Name   string
+	Source string
+}
+
+// Set is a set of templates that are parsed together, so they can execute each other by their names. Sets are not
+// changed after they are created, and overriding their templates returns new sets.
+type Set struct {
+	templates []Template
+	funcs     template.FuncMap
+	root      *template.Template
+}
+
+// Must returns the set of the templates with Funcs. It panics if a template does not parse, like template.Must, so
+// it is used for the templates of the generators.
+func Must(templates ...Template) *Set {
+	set, err := newSet(templates, Funcs)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+func newSet(templates []Template, funcs template.FuncMap) (*Set, error) {
+	root := template.New("").Funcs(funcs)
+	for _, t := range templates {
+		if _, err := root.New(t.Name).Parse(t.Source); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Set{templates: templates, funcs: funcs, root: root}, nil
+}
+
+// Has reports whether the set has a template, including the ones that the templates define.
+func (s *Set) Has(name string) bool {
+	return s.root.Lookup(name) != nil
+}
+
+// Execute applies the template of the name to the data.
+func (s *Set) Execute(w io.Writer, name string, data interface{}) error {
+	return s.root.ExecuteTemplate(w, name, data)
+}
+
+// ExecuteString applies the template of the name to the data and returns the result.
+func (s *Set) ExecuteString(name string, data interface{}) (string, error) {
+	var builder strings.Builder
+	if err := s.Execute(&builder, name, data); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// Override returns the sets with the templates of the files of a directory and the extra functions. Each file
+// overrides the template of its name in the set that has it, and a file whose name no set has is an error, except
+// the files whose names begin with _, which are added to every set. The extra functions are added to Funcs, and
+// they can replace them.
+func Override(dir string, funcs template.FuncMap, sets ...*Set) ([]*Set, error) {
+	overrides := make([][]Template, len(sets))
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		paths, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			name := strings.TrimSuffix(filepath.Base(path), Extension)
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			// files whose names begin with _ define templates for the other files, so every set has them
+			if strings.HasPrefix(name, "_") {
+				for i := range sets {
+					overrides[i] = append(overrides[i], Template{Name: name, Source: string(source)})
+				}
+				continue
+			}
+
+			index := -1
+			for i, set := range sets {
+				if set.Has(name) {
+					index = i
+					break
+				}
+			}
+			if index == -1 {
+				return nil, fmt.Errorf("%s: no template is named %s", path, name)
+			}
+			overrides[index] = append(overrides[index], Template{Name: name, Source: string(source)})
+		}
+	}
+
+	result := make([]*Set, len(sets))
+	for i, set := range sets {
+		merged := make(template.FuncMap, len(set.funcs)+len(funcs))
+		for name, function := range set.funcs {
+			merged[name] = function
+		}
+		for name, function := range funcs {
+			merged[name] = function
+		}
+
+		overridden, err := newSet(append(append([]Template{}, set.templates...), overrides[i]...), merged)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		result[i] = overridden
+	}
+
+	return result, nil
+}
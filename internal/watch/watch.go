@@ -0,0 +1,299 @@
+// Package watch generates targets, e.g. the repositories of a manifest, again when the files that they are generated
+// from change.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+// DefaultDelay is how long the changes of files settle before their targets are generated, e.g. while an editor
+// saves several files.
+const DefaultDelay = 200 * time.Millisecond
+
+// Target is generated again when one of its files changes.
+type Target struct {
+	// Name is the name of the target in the results, e.g. the destination of a repository
+	Name string
+	// Key is the definition of the target, e.g. its repository in the manifest. A target whose key changes when the
+	// targets are loaded again is generated again.
+	Key string
+	// Files are the files that the target is generated from, and a directory stands for its Go files
+	Files    []string
+	Generate func() ([]craftingtable.File, error)
+}
+
+// Loader returns the targets, and the files that they are loaded from, e.g. the manifest, which load them again when
+// they change. The files are returned with the error of loading too, so they are watched until it is fixed.
+type Loader func() (targets []Target, files []string, err error)
+
+// Options are the options of Watch.
+type Options struct {
+	// Delay is how long the changes settle before the targets are generated, DefaultDelay if it is zero
+	Delay time.Duration
+	// Out is where the results of the targets are printed
+	Out io.Writer
+}
+
+// Watch generates the targets, and then generates the targets of the files that change again until the context is
+// done. Only the generated files that differ from the files on disk are written. A target that fails is printed
+// with its problems, and it does not stop the other targets or the watch.
+func Watch(ctx context.Context, load Loader, options Options) error {
+	if options.Delay == 0 {
+		options.Delay = DefaultDelay
+	}
+	if options.Out == nil {
+		options.Out = io.Discard
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = fsWatcher.Close()
+	}()
+
+	w := &watcher{
+		load:      load,
+		out:       options.Out,
+		fsWatcher: fsWatcher,
+		dirs:      make(map[string]bool),
+		generated: make(map[string]bool),
+	}
+	w.reload()
+	w.generate(w.targets)
+	if len(w.dirs) == 0 {
+		return errors.New("no file to watch")
+	}
+	_, _ = fmt.Fprintln(w.out, "watching for changes, press Ctrl+C to stop")
+
+	timer := time.NewTimer(options.Delay)
+	timer.Stop()
+	changed := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			_, _ = fmt.Fprintln(w.out, err)
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod || w.generated[event.Name] || !w.watches(event.Name) {
+				continue
+			}
+			changed[event.Name] = true
+			timer.Reset(options.Delay)
+		case <-timer.C:
+			w.apply(changed)
+			changed = make(map[string]bool)
+		}
+	}
+}
+
+type watcher struct {
+	load      Loader
+	out       io.Writer
+	fsWatcher *fsnotify.Watcher
+
+	targets []Target
+	// files are the absolute paths of the files of the loader
+	files []string
+	// dirs are the watched directories, and generated are the files that the targets have written, whose events are
+	// not changes of their files
+	dirs      map[string]bool
+	generated map[string]bool
+}
+
+// reload loads the targets again, and returns the targets that are new or whose keys change. The previous targets
+// are kept if they cannot be loaded.
+func (w *watcher) reload() []Target {
+	targets, files, err := w.load()
+	if files != nil {
+		w.files = absolutePaths(files)
+		w.watch(w.files)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(w.out, err)
+		return nil
+	}
+
+	previous := make(map[string]string)
+	for _, target := range w.targets {
+		previous[target.Name] = target.Key
+	}
+
+	var changed []Target
+	for i := range targets {
+		targets[i].Files = absolutePaths(targets[i].Files)
+		w.watch(targets[i].Files)
+
+		if key, ok := previous[targets[i].Name]; !ok || key != targets[i].Key {
+			changed = append(changed, targets[i])
+		}
+	}
+	w.targets = targets
+
+	return changed
+}
+
+// apply generates the targets of changed files, after loading the targets again if the files of the loader change.
+func (w *watcher) apply(changed map[string]bool) {
+	var targets []Target
+	generating := make(map[string]bool)
+	if matchesAny(w.files, changed) {
+		targets = w.reload()
+		for _, target := range targets {
+			generating[target.Name] = true
+		}
+	}
+
+	for _, target := range w.targets {
+		if !generating[target.Name] && matchesAny(target.Files, changed) {
+			targets = append(targets, target)
+		}
+	}
+
+	w.generate(targets)
+}
+
+// generate generates targets, writes the files that differ from the files on disk and prints their results.
+func (w *watcher) generate(targets []Target) {
+	for _, target := range targets {
+		written, err := w.generateTarget(target)
+		switch {
+		case err != nil:
+			// diagnostics have the repositories and the lines of their problems
+			var diagnostics craftingtable.Diagnostics
+			if errors.As(err, &diagnostics) {
+				_, _ = fmt.Fprintln(w.out, err)
+			} else {
+				_, _ = fmt.Fprintf(w.out, "%s: %s\n", target.Name, err)
+			}
+		case len(written) == 0:
+			_, _ = fmt.Fprintf(w.out, "%s: up to date\n", target.Name)
+		default:
+			_, _ = fmt.Fprintf(w.out, "%s: wrote %s\n", target.Name, strings.Join(written, ", "))
+		}
+	}
+}
+
+// generateTarget generates a target and writes its files that differ from the files on disk, whose paths it returns.
+func (w *watcher) generateTarget(target Target) ([]string, error) {
+	files, err := target.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	return w.write(files)
+}
+
+// write writes the files that differ from the files on disk, and returns their paths.
+func (w *watcher) write(files []craftingtable.File) ([]string, error) {
+	changes, err := craftingtable.Plan(files)
+	if err != nil {
+		return nil, err
+	}
+
+	changedFiles := make([]craftingtable.File, len(changes))
+	written := make([]string, len(changes))
+	for i, change := range changes {
+		changedFiles[i] = craftingtable.File{Path: change.Path, Content: change.Content}
+		written[i] = change.Path
+	}
+	for _, file := range files {
+		if path, err := filepath.Abs(file.Path); err == nil {
+			w.generated[path] = true
+		}
+	}
+
+	return written, craftingtable.WriteFiles(changedFiles)
+}
+
+// watch watches the directories of files, since editors replace files when they save them.
+func (w *watcher) watch(files []string) {
+	for _, file := range files {
+		dir := file
+		if !isDir(file) {
+			dir = filepath.Dir(file)
+		}
+		if w.dirs[dir] || !isDir(dir) {
+			continue
+		}
+
+		if err := w.fsWatcher.Add(dir); err != nil {
+			_, _ = fmt.Fprintln(w.out, err)
+			continue
+		}
+		w.dirs[dir] = true
+	}
+}
+
+// watches reports whether a file is a file of the loader or of a target.
+func (w *watcher) watches(path string) bool {
+	if matches(w.files, path) {
+		return true
+	}
+	for _, target := range w.targets {
+		if matches(target.Files, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a path is one of files, or a Go file of one of their directories.
+func matches(files []string, path string) bool {
+	for _, file := range files {
+		if file == path {
+			return true
+		}
+		if strings.HasSuffix(path, ".go") && filepath.Dir(path) == file && isDir(file) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(files []string, paths map[string]bool) bool {
+	for path := range paths {
+		if matches(files, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func absolutePaths(paths []string) []string {
+	absolute := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		absolute = append(absolute, path)
+	}
+	return absolute
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,22 @@
+package craftingtable
+
+import "github.com/snapp-incubator/crafting-table/internal/annotation"
+
+// LoadAnnotations returns the manifest of the repositories that are declared by ct: comments on the structs of the
+// packages of patterns, e.g. "./...":
+//
+//	// ct:repository destination=../repository/user.go dialect=postgres
+//	// ct:get by=id,status
+//	// ct:select where=created_at:gte order=created_at:desc limit=50
+//	type User struct {
+//
+// Paths of the declarations are relative to the directory of the file of the struct, and the values that they do
+// not set are taken from the defaults. The problems of the manifest, including the ones that Generate returns, are
+// Diagnostics with their lines in the Go files.
+func LoadAnnotations(patterns []string, defaults Defaults) (*Manifest, error) {
+	manifest, err := annotation.Load(patterns, defaults.defaults())
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return manifestOf(manifest), nil
+}
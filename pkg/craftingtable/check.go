@@ -0,0 +1,35 @@
+package craftingtable
+
+import (
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/check"
+	"github.com/snapp-incubator/crafting-table/internal/ddl"
+)
+
+// Problem is a reference of a manifest that does not match a schema. File is the Go file of the problems of struct
+// fields, or empty for the problems of the manifest, and Line is the line of the problem in it.
+type Problem struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// Check resolves the tables and columns that the repositories of a manifest refer to in the CREATE TABLE statements
+// of a schema, and compares the types of the struct fields with the types of their columns. The dialect of the
+// schema is the dialect of the first repository if it is empty.
+func Check(manifest *Manifest, schema string, dialect Dialect) ([]Problem, error) {
+	if dialect == "" && len(manifest.Repositories) > 0 {
+		dialect = manifest.Repositories[0].Dialect
+	}
+
+	tables, err := ddl.Parse(schema, build.DialectType(dialect))
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, problem := range check.Check(manifest.manifest(), tables, build.DialectType(dialect)) {
+		problems = append(problems, Problem{File: problem.File, Line: problem.Line, Message: problem.Message})
+	}
+	return problems, nil
+}
@@ -0,0 +1,155 @@
+// Package craftingtable is the Go API of crafting-table, that the commands of crafting-table are built on. Its
+// functions return the generated files without writing them, so tools and `go generate` drivers can check, change or
+// write them, e.g. by WriteFiles.
+package craftingtable
+
+import (
+	"text/template"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/querybuilder"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+	"github.com/snapp-incubator/crafting-table/internal/templates"
+	"github.com/snapp-incubator/crafting-table/pkg/generator"
+)
+
+// File is a generated file.
+type File struct {
+	Path    string
+	Content string
+}
+
+// Change is a generated file that differs from the file on disk. Exists reports whether the file is on disk, and
+// Current is its content.
+type Change struct {
+	Path    string
+	Exists  bool
+	Current string
+	Content string
+}
+
+// UnifiedDiff returns the change as a unified diff from the file on disk to the generated file, like `git diff` does.
+func (c Change) UnifiedDiff() (string, error) {
+	return build.Change{Path: c.Path, Exists: c.Exists, Current: c.Current, Content: c.Content}.UnifiedDiff()
+}
+
+// Model is the struct of a repository.
+type Model = generator.Model
+
+// Options are the options of the generation of repositories.
+type Options struct {
+	// Tags selects the repositories of the manifest by a comma-separated tag filter, e.g. "billing,!legacy". Every
+	// repository is generated if it is empty.
+	Tags string
+	// Verifier verifies the queries of the repositories before their files are returned, or nil to not verify them,
+	// e.g. verify.SQLiteVerifier
+	Verifier Verifier
+	// Templates is the directory of the templates that override the default templates, or empty
+	Templates string
+	// Funcs are the functions of the templates besides the default ones, which they can replace
+	Funcs template.FuncMap
+}
+
+// Generate returns the repositories of a manifest and their test files. The problems of the manifest are returned
+// together as Diagnostics. The custom regions of the files on disk are carried over to them.
+func Generate(manifest *Manifest, options Options) ([]File, error) {
+	tmpl, _, err := loadTemplates(options.Templates, options.Funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	var verifier build.Verifier
+	if options.Verifier != nil {
+		verifier = queryVerifier{verifier: options.Verifier}
+	}
+
+	files, err := manifest.manifest().Render(options.Tags, verifier, tmpl)
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return filesOf(files), nil
+}
+
+// LoadManifest reads a manifest file. The values that a repository does not set are taken from the defaults.
+func LoadManifest(path string, defaults Defaults) (*Manifest, error) {
+	manifest, err := build.LoadManifest(path, defaults.defaults())
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return manifestOf(manifest), nil
+}
+
+// ParseManifest parses the content of a manifest, whose path is the manifest of its diagnostics.
+func ParseManifest(path string, content []byte, defaults Defaults) (*Manifest, error) {
+	manifest, err := build.ParseManifest(path, content, defaults.defaults())
+	if err != nil {
+		return nil, errorOf(err)
+	}
+	return manifestOf(manifest), nil
+}
+
+// ValidateManifest checks a manifest file without generating it, and returns its problems as Diagnostics.
+func ValidateManifest(path string, defaults Defaults) error {
+	return errorOf(build.ValidateManifest(path, defaults.defaults()))
+}
+
+// ManifestSchema returns the JSON Schema of manifest files.
+func ManifestSchema() ([]byte, error) {
+	return build.JSONSchema()
+}
+
+// Plan returns the generated files that differ from the files on disk.
+func Plan(files []File) ([]Change, error) {
+	changes, err := build.Plan(buildFiles(files))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Change, len(changes))
+	for i, change := range changes {
+		result[i] = Change{Path: change.Path, Exists: change.Exists, Current: change.Current, Content: change.Content}
+	}
+	return result, nil
+}
+
+// WriteFiles writes the generated files and creates their directories.
+func WriteFiles(files []File) error {
+	return build.WriteFiles(buildFiles(files))
+}
+
+// BindModel returns the model of a struct, or of the first struct if the name is empty. The source is a Go file, or
+// a directory or an import path of a package. The table of the model is the snake case of its name.
+func BindModel(source, structName string) (Model, error) {
+	s, err := structure.BindStruct(source, structName)
+	if err != nil {
+		return Model{}, err
+	}
+
+	return build.ModelOf(s, s.TableName), nil
+}
+
+// loadTemplates returns the templates of the repositories and the query builders, with the templates of the
+// directory instead of the default ones that they override.
+func loadTemplates(dir string, funcs template.FuncMap) (repository, queryBuilder *templates.Set, err error) {
+	sets, err := templates.Override(dir, funcs, build.DefaultTemplates, querybuilder.DefaultTemplates)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sets[0], sets[1], nil
+}
+
+func filesOf(files []build.File) []File {
+	result := make([]File, len(files))
+	for i, file := range files {
+		result[i] = File{Path: file.Path, Content: file.Content}
+	}
+	return result
+}
+
+func buildFiles(files []File) []build.File {
+	result := make([]build.File, len(files))
+	for i, file := range files {
+		result[i] = build.File{Path: file.Path, Content: file.Content}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package craftingtable
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// Diagnostic is a problem of a manifest that stops its repository from being generated.
+type Diagnostic struct {
+	// Manifest is the path of the manifest, and Line is the line of the problem in it, or 0 if it is not known
+	Manifest string
+	Line     int
+	// Repository is the destination of the repository that has the problem
+	Repository string
+	// Entry is the function entry of the repository, e.g. "select[1]", or empty for the problems of the repository
+	Entry string
+	// Function is the name of the function of the entry, if it is known
+	Function string
+	// Field is the yaml key of the entry or the repository that has the problem, e.g. "where_conditions"
+	Field   string
+	Message string
+}
+
+// String returns the diagnostic as "manifest.yaml:14: repository/user.go: select[1] (GetByName): field: message".
+func (d Diagnostic) String() string {
+	return build.Diagnostic{
+		Manifest:   d.Manifest,
+		Line:       d.Line,
+		Repository: d.Repository,
+		Entry:      d.Entry,
+		Function:   d.Function,
+		Field:      d.Field,
+		Message:    d.Message,
+	}.String()
+}
+
+// Diagnostics are the problems of a manifest, which are returned together.
+type Diagnostics []Diagnostic
+
+// Error returns the diagnostics, one per line.
+func (d Diagnostics) Error() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errorOf returns the Diagnostics of an error that has the diagnostics of a manifest, or the error itself.
+func errorOf(err error) error {
+	var diagnostics build.Diagnostics
+	if !errors.As(err, &diagnostics) {
+		return err
+	}
+
+	result := make(Diagnostics, len(diagnostics))
+	for i, d := range diagnostics {
+		result[i] = Diagnostic{
+			Manifest:   d.Manifest,
+			Line:       d.Line,
+			Repository: d.Repository,
+			Entry:      d.Entry,
+			Function:   d.Function,
+			Field:      d.Field,
+			Message:    d.Message,
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package craftingtable
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/ddl"
+)
+
+// ImportOptions are the options of the import of CREATE TABLE statements.
+type ImportOptions struct {
+	// Dialect is the dialect of the statements, MySQL if it is empty
+	Dialect Dialect
+	// ModelDir is the directory of the files of the structs, and ModelPackage is their package name, which is the
+	// name of the directory if it is empty
+	ModelDir     string
+	ModelPackage string
+	// ManifestPath is the path of the starter manifest of the structs
+	ManifestPath string
+	// RepositoryDir and RepositoryPackage are the directory and the package name of the repositories of the manifest
+	RepositoryDir     string
+	RepositoryPackage string
+	// RepositorySuffix replaces the .go extension of the file names of the repositories, e.g. _ct_gen.go
+	RepositorySuffix string
+}
+
+// ImportDDL returns the files of the structs of the tables of CREATE TABLE statements, and a starter manifest of
+// their repositories, which is the last file.
+func ImportDDL(schema string, options ImportOptions) ([]File, error) {
+	dialect := options.Dialect
+	if dialect == "" {
+		dialect = MySQL
+	}
+	switch dialect {
+	case MySQL, Postgres, SQLite3, SQLServer:
+	default:
+		return nil, fmt.Errorf("dialect %q is not supported", dialect)
+	}
+
+	tables, err := ddl.Parse(schema, build.DialectType(dialect))
+	if err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statement found")
+	}
+
+	packageName := options.ModelPackage
+	if packageName == "" {
+		absoluteDir, err := filepath.Abs(options.ModelDir)
+		if err != nil {
+			return nil, fmt.Errorf("Error in output directory: %w", err)
+		}
+		packageName = filepath.Base(absoluteDir)
+	}
+
+	var files []File
+	for _, table := range tables {
+		model, err := ddl.BuildModel(table, build.DialectType(dialect), packageName)
+		if err != nil {
+			return nil, fmt.Errorf("Error in building model: %w", err)
+		}
+
+		files = append(files, File{Path: filepath.Join(options.ModelDir, ddl.FileName(table)), Content: model})
+	}
+
+	manifest, err := ddl.BuildManifest(tables, ddl.ManifestOptions{
+		Dialect:           build.DialectType(dialect),
+		ModelDir:          filepath.ToSlash(options.ModelDir),
+		RepositoryDir:     filepath.ToSlash(options.RepositoryDir),
+		RepositoryPackage: options.RepositoryPackage,
+		RepositorySuffix:  options.RepositorySuffix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error in building manifest: %w", err)
+	}
+
+	return append(files, File{Path: options.ManifestPath, Content: manifest}), nil
+}
@@ -0,0 +1,454 @@
+package craftingtable
+
+import (
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+)
+
+// Manifest is a list of repositories that are generated together. Manifests are loaded from files by LoadManifest
+// or from annotations by LoadAnnotations, or built as Go values. The diagnostics of a loaded manifest have the lines
+// of its repositories, also after they are changed.
+type Manifest struct {
+	Repositories []Repository
+
+	// loaded is the manifest that the repositories are loaded from, which has their lines
+	loaded *build.Manifest
+}
+
+// Repository is a repository of a manifest, whose fields are the keys of the repositories of manifest files.
+type Repository struct {
+	Tags        []string
+	Source      string
+	Destination string
+	Dialect     Dialect
+	PackageName string
+	StructName  string
+	TableName   string
+	// TableNaming names the table when TableName is not set, SnakeCase if it is empty
+	TableNaming TableNaming
+	DBLibrary   string
+	Test        bool
+	Select      []Select
+	Insert      []Insert
+	Update      []Update
+	Delete      []Delete
+	// SoftDeleteColumn turns delete functions into updates that set the column to the current timestamp and makes
+	// select functions skip the deleted rows
+	SoftDeleteColumn string
+	// Sections are the other keys of the repository, which are the sections of the generators that are registered by
+	// generator.Register, e.g. archive
+	Sections map[string]interface{}
+}
+
+// Select is a select entry of a repository.
+type Select struct {
+	Type            SelectType
+	Fields          []string
+	FunctionName    string
+	AggregateFields []AggregateField
+	WhereConditions []WhereCondition
+	JoinFields      []JoinField
+	OrderBy         string
+	OrderType       OrderType
+	Limit           uint
+	GroupBy         []string
+}
+
+// Insert is an insert entry of a repository.
+type Insert struct {
+	Fields       []string
+	FunctionName string
+	WithObject   bool
+	OnConflict   *OnConflict
+	Bulk         bool
+	BatchSize    int
+}
+
+// Update is an update entry of a repository.
+type Update struct {
+	Fields          []string
+	WhereConditions []WhereCondition
+	FunctionName    string
+	WithObject      bool
+}
+
+// Delete is a delete entry of a repository. AllRows generates a function that deletes every row of the table, which
+// a delete without where conditions needs.
+type Delete struct {
+	WhereConditions []WhereCondition
+	FunctionName    string
+	AllRows         bool
+}
+
+// WhereCondition is a condition of the where clause of an entry.
+type WhereCondition struct {
+	Column   string
+	Operator OperatorType
+}
+
+// AggregateField is an aggregate function of a select entry, e.g. COUNT, whose result is named As.
+type AggregateField struct {
+	Function string
+	On       string
+	As       string
+}
+
+// JoinField is a join of a select entry.
+type JoinField struct {
+	Table    string
+	As       string
+	OnSource string
+	OnJoin   string
+	Function JoinType
+}
+
+// OnConflict is what an insert entry does when a row conflicts with the rows of the table.
+type OnConflict struct {
+	Columns       []string
+	Action        ConflictAction
+	UpdateColumns []string
+}
+
+// Defaults are the values of the repositories that their manifest does not set.
+type Defaults struct {
+	Dialect     Dialect
+	PackageName string
+	DBLibrary   string
+	TableNaming TableNaming
+	// Test is nil if the test generation is not set
+	Test *bool
+}
+
+// Dialect is the SQL dialect of a repository.
+type Dialect string
+
+const (
+	MySQL     Dialect = "mysql"
+	Postgres  Dialect = "postgres"
+	SQLite3   Dialect = "sqlite3"
+	SQLServer Dialect = "sqlserver"
+)
+
+// SelectType is the type of a select entry: get returns a row, and select returns rows.
+type SelectType string
+
+const (
+	SelectTypeSelect SelectType = "select"
+	SelectTypeGet    SelectType = "get"
+)
+
+// OrderType is the order of the rows of a select entry.
+type OrderType string
+
+const (
+	OrderTypeAsc  OrderType = "asc"
+	OrderTypeDesc OrderType = "desc"
+)
+
+// OperatorType is the operator of a where condition.
+type OperatorType string
+
+const (
+	OperatorTypeEqual     OperatorType = "equal"
+	OperatorTypeNotEqual  OperatorType = "not_equal"
+	OperatorTypeIn        OperatorType = "in"
+	OperatorTypeNotIn     OperatorType = "not_in"
+	OperatorTypeGt        OperatorType = "gt"
+	OperatorTypeGte       OperatorType = "gte"
+	OperatorTypeLt        OperatorType = "lt"
+	OperatorTypeLte       OperatorType = "lte"
+	OperatorTypeIsNull    OperatorType = "is_null"
+	OperatorTypeIsNotNull OperatorType = "is_not_null"
+)
+
+// JoinType is the function of a join field.
+type JoinType string
+
+const (
+	JoinTypeJoin         JoinType = "Join"
+	JoinTypeInner        JoinType = "inner"
+	JoinTypeFullOuter    JoinType = "fullOuter"
+	JoinTypeRightOuter   JoinType = "rightOuter"
+	JoinTypeLeftOuter    JoinType = "leftOuter"
+	JoinTypeFull         JoinType = "full"
+	JoinTypeLeft         JoinType = "left"
+	JoinTypeRight        JoinType = "right"
+	JoinTypeNatural      JoinType = "natural"
+	JoinTypeNaturalLeft  JoinType = "naturalLeft"
+	JoinTypeNaturalRight JoinType = "naturalRight"
+	JoinTypeNaturalFull  JoinType = "naturalFull"
+	JoinTypeCross        JoinType = "cross"
+)
+
+// ConflictAction is the action of an insert entry when a row conflicts with the rows of the table.
+type ConflictAction string
+
+const (
+	ConflictActionDoNothing ConflictAction = "do_nothing"
+	ConflictActionUpdate    ConflictAction = "update"
+)
+
+// TableNaming is how the table of a struct is named when its table name is not set.
+type TableNaming string
+
+const (
+	SnakeCase       TableNaming = "snake_case"
+	PluralSnakeCase TableNaming = "plural_snake_case"
+)
+
+// or returns the table naming, or the fallback if it is not set.
+func (n TableNaming) or(fallback TableNaming) structure.TableNaming {
+	return structure.TableNaming(n).Or(structure.TableNaming(fallback))
+}
+
+// Subset returns a manifest of the repositories at indexes of Repositories, whose diagnostics have the lines of the
+// repositories, e.g. to generate the repositories whose sources change again.
+func (m *Manifest) Subset(indexes ...int) *Manifest {
+	subset := &Manifest{}
+	for _, index := range indexes {
+		subset.Repositories = append(subset.Repositories, m.Repositories[index])
+	}
+	if m.loaded != nil && len(m.loaded.Repos) == len(m.Repositories) {
+		subset.loaded = m.loaded.Subset(indexes...)
+	}
+
+	return subset
+}
+
+// SelectRepositories returns the repositories that match a comma-separated tag filter, e.g. "billing,!legacy".
+// A repository matches if it has none of the negated tags and, when the filter has other tags, at least one of them.
+// An empty filter matches every repository.
+func (m *Manifest) SelectRepositories(filter string) []Repository {
+	var repositories []Repository
+	for _, repo := range m.manifest().SelectRepos(filter) {
+		repositories = append(repositories, repositoryOf(repo))
+	}
+
+	return repositories
+}
+
+// manifestOf returns the manifest of a loaded manifest.
+func manifestOf(loaded *build.Manifest) *Manifest {
+	m := &Manifest{loaded: loaded}
+	for _, repo := range loaded.Repos {
+		m.Repositories = append(m.Repositories, repositoryOf(repo))
+	}
+
+	return m
+}
+
+// manifest returns the manifest of the repositories, with the lines of the loaded manifest.
+func (m *Manifest) manifest() *build.Manifest {
+	repos := make([]build.Repo, len(m.Repositories))
+	for i, repository := range m.Repositories {
+		repos[i] = repository.repo()
+	}
+
+	if m.loaded == nil {
+		return build.NewManifest(repos, nil)
+	}
+	return m.loaded.WithRepos(repos)
+}
+
+func (d Defaults) defaults() build.Defaults {
+	return build.Defaults{
+		Dialect:     build.DialectType(d.Dialect),
+		PackageName: d.PackageName,
+		DBLibrary:   d.DBLibrary,
+		TableNaming: structure.TableNaming(d.TableNaming),
+		Test:        d.Test,
+	}
+}
+
+func repositoryOf(repo build.Repo) Repository {
+	repository := Repository{
+		Tags:             repo.AllTags(),
+		Source:           repo.Source,
+		Destination:      repo.Destination,
+		Dialect:          Dialect(repo.Dialect),
+		PackageName:      repo.PackageName,
+		StructName:       repo.StructName,
+		TableName:        repo.TableName,
+		TableNaming:      TableNaming(repo.TableNaming),
+		DBLibrary:        repo.DBLibrary,
+		Test:             repo.Test,
+		SoftDeleteColumn: repo.SoftDeleteColumn,
+		Sections:         repo.Sections,
+	}
+	for _, s := range repo.Select {
+		repository.Select = append(repository.Select, Select{
+			Type:            SelectType(s.Type),
+			Fields:          s.Fields,
+			FunctionName:    s.FunctionName,
+			AggregateFields: aggregateFieldsOf(s.AggregateFields),
+			WhereConditions: whereConditionsOf(s.WhereConditions),
+			JoinFields:      joinFieldsOf(s.JoinFields),
+			OrderBy:         s.OrderBy,
+			OrderType:       OrderType(s.OrderType),
+			Limit:           s.Limit,
+			GroupBy:         s.GroupBy,
+		})
+	}
+	for _, i := range repo.Insert {
+		insert := Insert{
+			Fields:       i.Fields,
+			FunctionName: i.FunctionName,
+			WithObject:   i.WithObject,
+			Bulk:         i.Bulk,
+			BatchSize:    i.BatchSize,
+		}
+		if i.OnConflict != nil {
+			insert.OnConflict = &OnConflict{
+				Columns:       i.OnConflict.Columns,
+				Action:        ConflictAction(i.OnConflict.Action),
+				UpdateColumns: i.OnConflict.UpdateColumns,
+			}
+		}
+		repository.Insert = append(repository.Insert, insert)
+	}
+	for _, u := range repo.Update {
+		repository.Update = append(repository.Update, Update{
+			Fields:          u.Fields,
+			WhereConditions: whereConditionsOf(u.WhereConditions),
+			FunctionName:    u.FunctionName,
+			WithObject:      u.WithObject,
+		})
+	}
+	for _, d := range repo.Delete {
+		repository.Delete = append(repository.Delete, Delete{
+			WhereConditions: whereConditionsOf(d.WhereConditions),
+			FunctionName:    d.FunctionName,
+			AllRows:         d.AllRows,
+		})
+	}
+
+	return repository
+}
+
+func (r Repository) repo() build.Repo {
+	repo := build.Repo{
+		Tags:             r.Tags,
+		Source:           r.Source,
+		Destination:      r.Destination,
+		Dialect:          build.DialectType(r.Dialect),
+		PackageName:      r.PackageName,
+		StructName:       r.StructName,
+		TableName:        r.TableName,
+		TableNaming:      structure.TableNaming(r.TableNaming),
+		DBLibrary:        r.DBLibrary,
+		Test:             r.Test,
+		SoftDeleteColumn: r.SoftDeleteColumn,
+		Sections:         r.Sections,
+	}
+	for _, s := range r.Select {
+		repo.Select = append(repo.Select, build.Select{
+			Type:            build.SelectType(s.Type),
+			Fields:          s.Fields,
+			FunctionName:    s.FunctionName,
+			AggregateFields: s.aggregateFields(),
+			WhereConditions: whereConditions(s.WhereConditions),
+			JoinFields:      s.joinFields(),
+			OrderBy:         s.OrderBy,
+			OrderType:       build.OrderType(s.OrderType),
+			Limit:           s.Limit,
+			GroupBy:         s.GroupBy,
+		})
+	}
+	for _, i := range r.Insert {
+		insert := build.Insert{
+			Fields:       i.Fields,
+			FunctionName: i.FunctionName,
+			WithObject:   i.WithObject,
+			Bulk:         i.Bulk,
+			BatchSize:    i.BatchSize,
+		}
+		if i.OnConflict != nil {
+			insert.OnConflict = &build.OnConflict{
+				Columns:       i.OnConflict.Columns,
+				Action:        build.ConflictActionType(i.OnConflict.Action),
+				UpdateColumns: i.OnConflict.UpdateColumns,
+			}
+		}
+		repo.Insert = append(repo.Insert, insert)
+	}
+	for _, u := range r.Update {
+		repo.Update = append(repo.Update, build.Update{
+			Fields:          u.Fields,
+			WhereConditions: whereConditions(u.WhereConditions),
+			FunctionName:    u.FunctionName,
+			WithObject:      u.WithObject,
+		})
+	}
+	for _, d := range r.Delete {
+		repo.Delete = append(repo.Delete, build.Delete{
+			WhereConditions: whereConditions(d.WhereConditions),
+			FunctionName:    d.FunctionName,
+			AllRows:         d.AllRows,
+		})
+	}
+
+	return repo
+}
+
+func whereConditionsOf(conditions []build.WhereCondition) []WhereCondition {
+	var whereConditions []WhereCondition
+	for _, c := range conditions {
+		whereConditions = append(whereConditions, WhereCondition{Column: c.Column, Operator: OperatorType(c.Operator)})
+	}
+	return whereConditions
+}
+
+func whereConditions(conditions []WhereCondition) []build.WhereCondition {
+	var whereConditions []build.WhereCondition
+	for _, c := range conditions {
+		whereConditions = append(whereConditions, build.WhereCondition{
+			Column:   c.Column,
+			Operator: build.OperatorType(c.Operator),
+		})
+	}
+	return whereConditions
+}
+
+func aggregateFieldsOf(fields []build.AggregateField) []AggregateField {
+	var aggregateFields []AggregateField
+	for _, f := range fields {
+		aggregateFields = append(aggregateFields, AggregateField{Function: f.Function, On: f.On, As: f.As})
+	}
+	return aggregateFields
+}
+
+func (s Select) aggregateFields() []build.AggregateField {
+	var aggregateFields []build.AggregateField
+	for _, f := range s.AggregateFields {
+		aggregateFields = append(aggregateFields, build.AggregateField{Function: f.Function, On: f.On, As: f.As})
+	}
+	return aggregateFields
+}
+
+func joinFieldsOf(fields []build.JoinField) []JoinField {
+	var joinFields []JoinField
+	for _, f := range fields {
+		joinFields = append(joinFields, JoinField{
+			Table:    f.Table,
+			As:       f.As,
+			OnSource: f.OnSource,
+			OnJoin:   f.OnJoin,
+			Function: JoinType(f.Function),
+		})
+	}
+	return joinFields
+}
+
+func (s Select) joinFields() []build.JoinField {
+	var joinFields []build.JoinField
+	for _, f := range s.JoinFields {
+		joinFields = append(joinFields, build.JoinField{
+			Table:    f.Table,
+			As:       f.As,
+			OnSource: f.OnSource,
+			OnJoin:   f.OnJoin,
+			Function: build.JoinType(f.Function),
+		})
+	}
+	return joinFields
+}
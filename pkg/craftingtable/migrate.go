@@ -0,0 +1,59 @@
+package craftingtable
+
+import (
+	"errors"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/migrate"
+)
+
+// MigrationOptions are the options of the generation of migrations.
+type MigrationOptions struct {
+	// Dialect is the dialect of the migrations, MySQL if it is empty
+	Dialect Dialect
+	// TableNaming names the tables of the structs, PluralSnakeCase if it is empty
+	TableNaming TableNaming
+	// Dir is the directory of the migrations and their snapshot, and Name is the name of the migration files
+	Dir  string
+	Name string
+	// OutputSuffix is the suffix of the files of the query builders, which are skipped, DefaultQueryBuilderSuffix if
+	// it is empty
+	OutputSuffix string
+}
+
+// Migration returns the up and down migrations of the structs that are annotated with `ct: model` in a file, a
+// directory or a package since the last migration of the directory, and the new snapshot of the directory. There
+// are no files if the structs are not changed.
+func Migration(path string, options MigrationOptions) ([]File, error) {
+	dialect := options.Dialect
+	if dialect == "" {
+		dialect = MySQL
+	}
+
+	suffix := options.OutputSuffix
+	if suffix == "" {
+		suffix = DefaultQueryBuilderSuffix
+	}
+
+	schema, err := migrate.LoadSchema(path, build.DialectType(dialect), options.TableNaming.or(PluralSnakeCase), suffix)
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.Tables) == 0 {
+		return nil, errors.New("no struct annotated with `ct: model` found")
+	}
+
+	migration, err := migrate.Plan(options.Dir, options.Name, schema)
+	if err != nil {
+		return nil, err
+	}
+	if migration == nil {
+		return nil, nil
+	}
+
+	files, err := migration.Files(options.Dir, schema)
+	if err != nil {
+		return nil, err
+	}
+	return filesOf(files), nil
+}
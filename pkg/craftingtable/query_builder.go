@@ -0,0 +1,81 @@
+package craftingtable
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/snapp-incubator/crafting-table/internal/querybuilder"
+	"github.com/snapp-incubator/crafting-table/internal/structure"
+)
+
+// DefaultQueryBuilderSuffix is the suffix of the files of the query builders when the options do not set one.
+const DefaultQueryBuilderSuffix = "_ct_gen.go"
+
+// QueryBuilderOptions are the options of the generation of query builders.
+type QueryBuilderOptions struct {
+	// Dialect is the dialect of the queries, MySQL if it is empty
+	Dialect Dialect
+	// TableNaming names the tables of the structs, PluralSnakeCase if it is empty
+	TableNaming TableNaming
+	// OutputSuffix replaces the extension of the file of the structs in the file of their query builders,
+	// DefaultQueryBuilderSuffix if it is empty
+	OutputSuffix string
+	// Templates is the directory of the templates that override the default templates, or empty
+	Templates string
+	// Funcs are the functions of the templates besides the default ones, which they can replace
+	Funcs template.FuncMap
+}
+
+// QueryBuilders returns the query builders of the structs that are annotated with `ct: model` in a file, a
+// directory or a package. The query builders of the structs of a file are in one file next to it.
+func QueryBuilders(path string, options QueryBuilderOptions) ([]File, error) {
+	dialect := options.Dialect
+	if dialect == "" {
+		dialect = MySQL
+	}
+	suffix := options.OutputSuffix
+	if suffix == "" {
+		suffix = DefaultQueryBuilderSuffix
+	}
+
+	_, tmpl, err := loadTemplates(options.Templates, options.Funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := structure.LoadPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, fileAst := range structure.SourceFiles(pkg, path, suffix) {
+		sourceFilePath := pkg.Fset.File(fileAst.Pos()).Name()
+
+		var outputs []string
+		for _, decl := range fileAst.Decls {
+			if genDecl, ok := decl.(*ast.GenDecl); ok {
+				if strings.HasPrefix(genDecl.Doc.Text(), querybuilder.ModelAnnotation) {
+					output, err := querybuilder.Generate(string(dialect), options.TableNaming.or(PluralSnakeCase), tmpl,
+						pkg.Types, pkg.TypesInfo, genDecl)
+					if err != nil {
+						return nil, err
+					}
+					outputs = append(outputs, output)
+				}
+			}
+		}
+		if len(outputs) == 0 {
+			continue
+		}
+
+		files = append(files, File{
+			Path:    strings.TrimSuffix(sourceFilePath, filepath.Ext(sourceFilePath)) + suffix,
+			Content: strings.Join(outputs, ""),
+		})
+	}
+
+	return files, nil
+}
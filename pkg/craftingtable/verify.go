@@ -0,0 +1,67 @@
+package craftingtable
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+)
+
+// Verifier checks the queries of a repository before its files are returned, e.g. against the schema of its tables.
+// The queries that do not verify are returned as QueryErrors, which are the Diagnostics of their entries, and other
+// errors stop the verification, e.g. a schema that can not be parsed.
+type Verifier interface {
+	Verify(repository Repository, queries []Query) error
+}
+
+// Query is the query of a generated function.
+type Query struct {
+	Function string
+	Query    string
+}
+
+// QueryError is the error of a query that does not verify. Index is the index of the query in the verified queries.
+type QueryError struct {
+	Index int
+	Err   error
+}
+
+func (e QueryError) Error() string {
+	return e.Err.Error()
+}
+
+// QueryErrors are the errors of the queries that do not verify.
+type QueryErrors []QueryError
+
+func (e QueryErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// queryVerifier is the build.Verifier of a Verifier.
+type queryVerifier struct {
+	verifier Verifier
+}
+
+func (v queryVerifier) Verify(repo build.Repo, statements []build.Statement) error {
+	queries := make([]Query, len(statements))
+	for i, statement := range statements {
+		queries[i] = Query{Function: statement.FunctionName, Query: statement.Query}
+	}
+
+	err := v.verifier.Verify(repositoryOf(repo), queries)
+
+	var queryErrors QueryErrors
+	if !errors.As(err, &queryErrors) {
+		return err
+	}
+
+	statementErrors := make(build.StatementErrors, len(queryErrors))
+	for i, queryError := range queryErrors {
+		statementErrors[i] = build.StatementError{Index: queryError.Index, Err: queryError.Err}
+	}
+	return statementErrors
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Response is the output of an executable generator for an entry: the function of the entry, or the problems of its
+// fields.
+type Response struct {
+	Function Function    `json:"function"`
+	Errors   FieldErrors `json:"errors,omitempty"`
+}
+
+// Exec returns the generator of a section that runs a command for every entry. The command reads the Request of the
+// entry as JSON from its standard input and writes the Response as JSON to its standard output. If it exits with an
+// error, its standard error is the error of the entry.
+func Exec(section string, name string, args ...string) Generator {
+	return &execGenerator{section: section, name: name, args: args}
+}
+
+type execGenerator struct {
+	section string
+	name    string
+	args    []string
+}
+
+func (g *execGenerator) Section() string {
+	return g.section
+}
+
+func (g *execGenerator) Generate(request Request) (Function, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return Function{}, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(g.name, g.args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return Function{}, fmt.Errorf("%s: %s", g.name, message)
+		}
+		return Function{}, fmt.Errorf("%s: %w", g.name, err)
+	}
+
+	var response Response
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return Function{}, fmt.Errorf("%s: invalid response: %w", g.name, err)
+	}
+	if len(response.Errors) > 0 {
+		return Function{}, response.Errors
+	}
+
+	return response.Function, nil
+}
@@ -0,0 +1,172 @@
+// Package generator is the API of the generators of the functions of repositories. Every section of a manifest
+// repository, e.g. select or insert, is generated by a generator, and sections that crafting-table does not have,
+// e.g. archive, can be added by registering their generators, as Go code, Go plugins or executables.
+package generator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Generator generates the functions of the entries of a section of manifest repositories.
+type Generator interface {
+	// Section is the yaml key of the section in a repository, e.g. archive
+	Section() string
+	// Generate returns the function of an entry of the section. The problems of the entry are returned as
+	// FieldError or FieldErrors, so they are reported with their lines in the manifest.
+	Generate(request Request) (Function, error)
+}
+
+// Request is an entry of a section of a repository, with the repository and its model.
+type Request struct {
+	Repository Repository `json:"repository"`
+	Model      Model      `json:"model"`
+	Section    string     `json:"section"`
+	// Index is the index of the entry in the section
+	Index int `json:"index"`
+	// Entry is the value of the entry, which is decoded from the manifest into maps, lists and scalars, or the
+	// struct of the entry for the sections of crafting-table
+	Entry interface{} `json:"entry"`
+}
+
+// Decode decodes the entry into the value that v points to, by the yaml keys of its fields.
+func (r Request) Decode(v interface{}) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("decode of %s entry into non-pointer %T", r.Section, v)
+	}
+	if r.Entry != nil && reflect.TypeOf(r.Entry) == value.Elem().Type() {
+		value.Elem().Set(reflect.ValueOf(r.Entry))
+		return nil
+	}
+
+	content, err := yaml.Marshal(r.Entry)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(content, v)
+}
+
+// Repository is the repository of an entry, as its manifest sets it.
+type Repository struct {
+	PackageName      string `json:"package_name"`
+	Destination      string `json:"destination"`
+	Dialect          string `json:"dialect"`
+	SoftDeleteColumn string `json:"soft_delete_column,omitempty"`
+	Test             bool   `json:"test"`
+}
+
+// Model is the struct of a repository.
+type Model struct {
+	// Name is the name of the struct, and PackageName is the name of its package
+	Name        string `json:"name"`
+	PackageName string `json:"package_name"`
+	// TableName is the table of the repository
+	TableName string  `json:"table_name"`
+	Fields    []Field `json:"fields"`
+	// Imports are the packages that the types of the fields refer to, by import path to package name
+	Imports map[string]string `json:"imports"`
+}
+
+// Field is a field of a model.
+type Field struct {
+	Name string `json:"name"`
+	// Type is the type of the field as the repository refers to it, e.g. *time.Time
+	Type string `json:"type"`
+	// Column is the column of the field, which is its db tag or the snake case of its name
+	Column string `json:"column"`
+	// Tag is the whole tag of the field
+	Tag string `json:"tag,omitempty"`
+}
+
+// Function is a generated function of a repository.
+type Function struct {
+	// Name is the name of the method, e.g. ArchiveById
+	Name string `json:"name"`
+	// Signature is the method in the repository interface, e.g. "ArchiveById(ctx context.Context, id int64) error"
+	Signature string `json:"signature"`
+	// Source is the method of the repository, whose receiver is d *database<Model>, e.g. d *databaseUser
+	Source string `json:"source"`
+	// Test is the test function of the method in the test file of the repository, or empty if it has none
+	Test string `json:"test,omitempty"`
+	// Imports are the import paths that the method and its test use besides the standard library and the imports of
+	// the model
+	Imports []string `json:"imports,omitempty"`
+	// Query is the query of the method, which is verified with the other queries of the repository when they are
+	// verified against a schema, or empty if it is not verified
+	Query string `json:"query,omitempty"`
+}
+
+// FieldError is a problem of a field of a manifest entry, e.g. a where column that the struct does not have.
+type FieldError struct {
+	// Field is the yaml key of the field, e.g. "where_conditions"
+	Field string `json:"field"`
+	// Value is the value of the field that has the problem, which is used to find its line in the manifest
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// FieldErrors are the problems of several fields of a manifest entry.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+var (
+	mutex      sync.RWMutex
+	generators = make(map[string]Generator)
+)
+
+// Register adds the generator of a section to the generators of every repository. A section can only have one
+// generator.
+func Register(g Generator) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	section := g.Section()
+	if section == "" {
+		return fmt.Errorf("generator %T has no section", g)
+	}
+	if _, ok := generators[section]; ok {
+		return fmt.Errorf("section %s already has a generator", section)
+	}
+
+	generators[section] = g
+	return nil
+}
+
+// Lookup returns the registered generator of a section, or nil if it has none.
+func Lookup(section string) Generator {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return generators[section]
+}
+
+// Sections returns the sections of the registered generators in order.
+func Sections() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	sections := make([]string, 0, len(generators))
+	for section := range generators {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	return sections
+}
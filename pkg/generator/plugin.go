@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// Symbol is the variable of a Go plugin that is its generator, e.g.
+//
+//	var Generator generator.Generator = archive{}
+const Symbol = "Generator"
+
+// Open loads the generator of a Go plugin, which is built with `go build -buildmode=plugin`. Go plugins are only
+// supported on Linux, FreeBSD and macOS, and they must be built with the same version of Go and of this module as
+// crafting-table.
+func Open(path string) (Generator, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	g, ok := symbol.(*Generator)
+	if !ok || *g == nil {
+		return nil, fmt.Errorf("%s: %s is not a generator.Generator", path, Symbol)
+	}
+	return *g, nil
+}
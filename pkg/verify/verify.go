@@ -0,0 +1,267 @@
+// Package verify verifies the generated queries of crafting-table against the schema of their tables.
+package verify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/internal/ddl"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+// SQLiteVerifier is a craftingtable.Verifier that prepares the queries of the repositories in an in-memory SQLite
+// database that has the tables of a schema file. Queries of the other dialects are prepared as well, after their
+// quotes and placeholders are rewritten for SQLite. The statements that SQLite does not have, ON DUPLICATE KEY UPDATE
+// of MySQL and MERGE of SQL Server, are reported to Out as not verifiable for their dialect, and they are not errors.
+type SQLiteVerifier struct {
+	// Out is where the queries that are not verifiable for their dialect are reported, os.Stderr by default
+	Out io.Writer
+
+	schemaPath string
+	schema     string
+	// databases are the databases of the schema by the dialect that it is parsed with
+	databases map[build.DialectType]*sql.DB
+}
+
+// NewSQLiteVerifier returns the verifier of the schema of a file.
+func NewSQLiteVerifier(schemaPath string) (*SQLiteVerifier, error) {
+	schema, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSchemaVerifier(schemaPath, string(schema)), nil
+}
+
+// NewSchemaVerifier returns the verifier of the CREATE TABLE statements of a schema. The name of the schema is its
+// name in the errors of the schema, e.g. the path of its file.
+func NewSchemaVerifier(name, schema string) *SQLiteVerifier {
+	return &SQLiteVerifier{
+		Out:        os.Stderr,
+		schemaPath: name,
+		schema:     schema,
+		databases:  make(map[build.DialectType]*sql.DB),
+	}
+}
+
+// Verify prepares every query, and returns the queries that do not prepare as craftingtable.QueryErrors.
+func (v *SQLiteVerifier) Verify(repository craftingtable.Repository, queries []craftingtable.Query) error {
+	dialect := build.DialectType(repository.Dialect)
+	db, err := v.database(dialect)
+	if err != nil {
+		return err
+	}
+
+	var queryErrors craftingtable.QueryErrors
+	ctx := context.Background()
+	for i, q := range queries {
+		if construct := unverifiableConstruct(dialect, q.Query); construct != "" {
+			_, _ = fmt.Fprintf(v.Out, "%s: %s: query is not verifiable for %s, since SQLite does not have %s\n\t%s\n",
+				repository.Destination, q.Function, dialect, construct, q.Query)
+			continue
+		}
+
+		// EXPLAIN compiles the query without running it, and the parameters are bound to NULL
+		query, parameters := sqliteQuery(dialect, q.Query)
+		rows, err := db.QueryContext(ctx, "EXPLAIN "+query, make([]interface{}, parameters)...)
+		if err != nil {
+			queryErrors = append(queryErrors, craftingtable.QueryError{
+				Index: i,
+				Err:   fmt.Errorf("query does not prepare: %s\n\t%s", err, q.Query),
+			})
+			continue
+		}
+		_ = rows.Close()
+	}
+
+	if len(queryErrors) > 0 {
+		return queryErrors
+	}
+	return nil
+}
+
+// unverifiableConstructs are the statements of the dialects that SQLite does not have
+var unverifiableConstructs = map[build.DialectType][]struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	build.MySQL:     {{"ON DUPLICATE KEY UPDATE", regexp.MustCompile(`(?i)\sON\s+DUPLICATE\s+KEY\s+UPDATE\s`)}},
+	build.SQLServer: {{"MERGE", regexp.MustCompile(`(?i)^\s*MERGE\s`)}},
+}
+
+// unverifiableConstruct returns the statement of a query that SQLite does not have, or empty if SQLite has the
+// statements of the query.
+func unverifiableConstruct(dialect build.DialectType, query string) string {
+	for _, construct := range unverifiableConstructs[dialect] {
+		if construct.pattern.MatchString(query) {
+			return construct.name
+		}
+	}
+	return ""
+}
+
+// topClause is the TOP clause of the select queries of SQL Server, that limits the rows
+var topClause = regexp.MustCompile(`(?i)^(\s*SELECT)\s+TOP\s*\([^)]*\)`)
+
+// deleteTable is the table of the delete queries of MySQL before their FROM clause
+var deleteTable = regexp.MustCompile("(?i)^(\\s*DELETE)\\s+`(?:[^`]|``)*`\\s+(FROM\\s)")
+
+// sqliteQuery rewrites the double-quoted identifiers of a query with backticks, since SQLite takes the double-quoted
+// identifiers that are not found as strings, and removes the TOP clause of SQL Server and the table before the FROM
+// clause of the delete queries of MySQL. The placeholders of every dialect, e.g. $1, @p1 and :name, are rewritten
+// with ?, whose number it returns.
+func sqliteQuery(dialect build.DialectType, query string) (string, int) {
+	switch dialect {
+	case build.SQLServer:
+		query = topClause.ReplaceAllString(query, "$1")
+	case build.MySQL:
+		query = deleteTable.ReplaceAllString(query, "$1 $2")
+	}
+
+	var builder strings.Builder
+	var quote byte
+	parameters := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote == 0 && c == '?':
+			parameters++
+			for i+1 < len(query) && isDigit(query[i+1]) {
+				i++
+			}
+		case quote == 0 && (c == '$' || c == '@' || c == ':') && i+1 < len(query) && isNameByte(query[i+1]) &&
+			(i == 0 || query[i-1] != ':'):
+			parameters++
+			builder.WriteByte('?')
+			for i+1 < len(query) && isNameByte(query[i+1]) {
+				i++
+			}
+			continue
+		case quote == 0 && (c == '\'' || c == '"' || c == '`'):
+			quote = c
+			if c == '"' {
+				c = '`'
+			}
+		case quote == '"' && c == '"' && i+1 < len(query) && query[i+1] == '"':
+			// an escaped double quote of the identifier
+			builder.WriteByte('"')
+			i++
+			continue
+		case quote == '"' && c == '`':
+			builder.WriteByte('`')
+		case quote != 0 && c == quote:
+			if quote == '\'' && i+1 < len(query) && query[i+1] == '\'' {
+				builder.WriteString("''")
+				i++
+				continue
+			}
+			if quote == '"' {
+				c = '`'
+			}
+			quote = 0
+		}
+		builder.WriteByte(c)
+	}
+
+	return builder.String(), parameters
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Close closes the databases of the schema.
+func (v *SQLiteVerifier) Close() error {
+	for _, db := range v.databases {
+		if err := db.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// database returns the database of the schema that is parsed with the dialect.
+func (v *SQLiteVerifier) database(dialect build.DialectType) (*sql.DB, error) {
+	if db, ok := v.databases[dialect]; ok {
+		return db, nil
+	}
+
+	tables, err := ddl.Parse(v.schema, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("error in parsing %s: %w", v.schemaPath, err)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// every connection has its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	for _, statement := range createStatements(tables) {
+		if _, err := db.Exec(statement); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("error in creating schema of %s: %w\n\t%s", v.schemaPath, err, statement)
+		}
+	}
+
+	v.databases[dialect] = db
+	return db, nil
+}
+
+// createStatements returns the statements that create the tables in SQLite. Columns have no types, since only their
+// names are needed to prepare the queries, and the schemas of the qualified tables are attached databases.
+func createStatements(tables []*ddl.Table) []string {
+	var statements []string
+	attached := map[string]bool{"main": true, "temp": true}
+	for _, table := range tables {
+		name := quote(table.Name)
+		if index := strings.LastIndex(table.Name, "."); index != -1 {
+			schema := table.Name[:index]
+			if !attached[strings.ToLower(schema)] {
+				attached[strings.ToLower(schema)] = true
+				statements = append(statements, fmt.Sprintf("ATTACH DATABASE ':memory:' AS %s", quote(schema)))
+			}
+			name = quote(schema) + "." + quote(table.Name[index+1:])
+		}
+
+		definitions := make([]string, 0, len(table.Columns)+len(table.UniqueKeys)+1)
+		for _, column := range table.Columns {
+			definitions = append(definitions, quote(column.Name))
+		}
+		if len(table.PrimaryKey) > 0 {
+			definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)", quoteList(table.PrimaryKey)))
+		}
+		for _, key := range table.UniqueKeys {
+			definitions = append(definitions, fmt.Sprintf("UNIQUE (%s)", quoteList(key)))
+		}
+
+		statements = append(statements, fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(definitions, ", ")))
+	}
+
+	return statements
+}
+
+func quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
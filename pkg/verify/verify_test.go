@@ -0,0 +1,219 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snapp-incubator/crafting-table/internal/build"
+	"github.com/snapp-incubator/crafting-table/pkg/craftingtable"
+)
+
+func TestUnverifiableConstruct(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   build.DialectType
+		query     string
+		construct string
+	}{
+		{
+			name:      "on duplicate key update of mysql",
+			dialect:   build.MySQL,
+			query:     "INSERT INTO `users` (`name`) VALUES (?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)",
+			construct: "ON DUPLICATE KEY UPDATE",
+		},
+		{
+			name:    "insert of mysql",
+			dialect: build.MySQL,
+			query:   "INSERT INTO `users` (`name`) VALUES (?)",
+		},
+		{
+			name:      "merge of sqlserver",
+			dialect:   build.SQLServer,
+			query:     `MERGE INTO "users" USING (VALUES (@p1)) AS "source" ("name") ON 1=1`,
+			construct: "MERGE",
+		},
+		{
+			name:    "merge column of sqlserver",
+			dialect: build.SQLServer,
+			query:   `SELECT "merge" FROM "users"`,
+		},
+		{
+			name:    "on conflict of postgres",
+			dialect: build.Postgres,
+			query:   `INSERT INTO "users" ("name") VALUES ($1) ON CONFLICT ("name") DO NOTHING`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.construct, unverifiableConstruct(test.dialect, test.query))
+		})
+	}
+}
+
+func TestSQLiteQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		dialect    build.DialectType
+		query      string
+		sqlite     string
+		parameters int
+	}{
+		{
+			name:       "placeholders of mysql",
+			dialect:    build.MySQL,
+			query:      "SELECT `id` FROM `users` WHERE `name` = ? AND `age` > ?",
+			sqlite:     "SELECT `id` FROM `users` WHERE `name` = ? AND `age` > ?",
+			parameters: 2,
+		},
+		{
+			name:       "delete table of mysql",
+			dialect:    build.MySQL,
+			query:      "DELETE `users` FROM `users` WHERE `id` = ?",
+			sqlite:     "DELETE FROM `users` WHERE `id` = ?",
+			parameters: 1,
+		},
+		{
+			name:       "quotes and placeholders of postgres",
+			dialect:    build.Postgres,
+			query:      `SELECT "id" FROM "users" WHERE "name" = $1 AND "role" = $2`,
+			sqlite:     "SELECT `id` FROM `users` WHERE `name` = ? AND `role` = ?",
+			parameters: 2,
+		},
+		{
+			name:       "cast of postgres",
+			dialect:    build.Postgres,
+			query:      `SELECT "id" FROM "users" WHERE "created_at" > $1::timestamp`,
+			sqlite:     "SELECT `id` FROM `users` WHERE `created_at` > ?::timestamp",
+			parameters: 1,
+		},
+		{
+			name:       "strings",
+			dialect:    build.Postgres,
+			query:      `SELECT "id" FROM "users" WHERE "name" = 'it''s $1' AND "note" = '"?"'`,
+			sqlite:     "SELECT `id` FROM `users` WHERE `name` = 'it''s $1' AND `note` = '\"?\"'",
+			parameters: 0,
+		},
+		{
+			name:       "escaped quotes of identifiers",
+			dialect:    build.Postgres,
+			query:      `SELECT "a""b", "c` + "`" + `d" FROM "users"`,
+			sqlite:     "SELECT `a\"b`, `c``d` FROM `users`",
+			parameters: 0,
+		},
+		{
+			name:       "top and placeholders of sqlserver",
+			dialect:    build.SQLServer,
+			query:      `SELECT TOP (1) "id" FROM "users" WHERE "name" = @p1`,
+			sqlite:     "SELECT `id` FROM `users` WHERE `name` = ?",
+			parameters: 1,
+		},
+		{
+			name:       "numbered placeholders of sqlite",
+			dialect:    build.SQLite3,
+			query:      "SELECT `id` FROM `users` WHERE `name` = ?1 OR `email` = :email",
+			sqlite:     "SELECT `id` FROM `users` WHERE `name` = ? OR `email` = ?",
+			parameters: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sqlite, parameters := sqliteQuery(test.dialect, test.query)
+			assert.Equal(t, test.sqlite, sqlite)
+			assert.Equal(t, test.parameters, parameters)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	schema := `
+CREATE TABLE users (
+    id bigint PRIMARY KEY,
+    name varchar(255) NOT NULL UNIQUE
+);`
+
+	tests := []struct {
+		name    string
+		dialect craftingtable.Dialect
+		queries []string
+		// errors are the indexes of the queries that do not verify, and unverifiable is whether a query is reported
+		// as not verifiable
+		errors       []int
+		unverifiable bool
+	}{
+		{
+			name:    "queries that prepare",
+			dialect: craftingtable.Postgres,
+			queries: []string{
+				`SELECT "id", "name" FROM "users" WHERE "id" = $1`,
+				`UPDATE "users" SET "name" = $1 WHERE "id" = $2`,
+			},
+		},
+		{
+			name:    "queries that do not prepare",
+			dialect: craftingtable.Postgres,
+			queries: []string{
+				`SELECT "id" FROM "users" WHERE "emial" = $1`,
+				`SELECT "id" FROM "users"`,
+				`SELECT "id" FROM "roles"`,
+			},
+			errors: []int{0, 2},
+		},
+		{
+			name:    "statements that sqlite does not have",
+			dialect: craftingtable.MySQL,
+			queries: []string{
+				"INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)",
+				"INSERT INTO `users` (`id`, `name`) VALUES (?, ?)",
+			},
+			unverifiable: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := NewSchemaVerifier("schema.sql", schema)
+			defer func() {
+				require.NoError(t, verifier.Close())
+			}()
+			var out bytes.Buffer
+			verifier.Out = &out
+
+			queries := make([]craftingtable.Query, len(test.queries))
+			for i, query := range test.queries {
+				queries[i] = craftingtable.Query{Function: "Function", Query: query}
+			}
+
+			err := verifier.Verify(craftingtable.Repository{Dialect: test.dialect}, queries)
+			if len(test.errors) == 0 {
+				require.NoError(t, err)
+			} else {
+				var queryErrors craftingtable.QueryErrors
+				require.ErrorAs(t, err, &queryErrors)
+
+				var indexes []int
+				for _, queryError := range queryErrors {
+					indexes = append(indexes, queryError.Index)
+				}
+				assert.Equal(t, test.errors, indexes)
+			}
+
+			assert.Equal(t, test.unverifiable, out.Len() > 0, out.String())
+		})
+	}
+}
+
+func TestVerifySchemaError(t *testing.T) {
+	verifier := NewSchemaVerifier("schema.sql", "CREATE TABLE users (id int, PRIMARY KEY (uid));")
+	defer func() {
+		require.NoError(t, verifier.Close())
+	}()
+
+	err := verifier.Verify(craftingtable.Repository{Dialect: craftingtable.Postgres}, nil)
+	require.Error(t, err)
+	assert.Equal(t, "error in parsing schema.sql: line 1: primary key column uid not found in table users", err.Error())
+}